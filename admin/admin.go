@@ -0,0 +1,165 @@
+// Package admin implement the Admin service of adminpb/admin.proto to manage writers remotely.
+//
+// The package does not depend on gRPC, adminpb.Register serve a Server on a grpc.Server.
+package admin
+
+import (
+	"context"
+	"errors"
+	"github.com/AlfredAlan/rotate"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrWriterNameIsEmpty = errors.New("error: writer name is empty")
+	ErrWriterNotFound    = errors.New("error: writer not found")
+)
+
+type (
+	// Server manage writers by name, it is safe for concurrent use
+	Server struct {
+		mu      sync.RWMutex
+		writers map[string]*rotate.RotateWriter
+	}
+
+	// ListWritersRequest
+	ListWritersRequest struct{}
+
+	// ListWritersResponse
+	ListWritersResponse struct {
+		Names []string
+	}
+
+	// RotateRequest
+	RotateRequest struct {
+		Name string
+	}
+
+	// RotateResponse Backup is empty when there was nothing to back up
+	RotateResponse struct {
+		Backup string
+	}
+
+	// GetStatsRequest
+	GetStatsRequest struct {
+		Name string
+	}
+
+	// GetStatsResponse mirror rotate.Stats, LastError is empty without error
+	GetStatsResponse struct {
+		BytesWritten          int64
+		Writes                int64
+		Rotations             int64
+		Compressions          int64
+		CompressDurationNanos int64
+		DeletedBackups        int64
+		Dropped               int64
+		Suppressed            int64
+		QueueDepth            int64
+		LastError             string
+	}
+
+	// SetRetentionRequest
+	SetRetentionRequest struct {
+		Name       string
+		MaxBackups int64
+		MaxDays    int64
+	}
+
+	// SetRetentionResponse
+	SetRetentionResponse struct{}
+)
+
+// NewServer
+func NewServer() *Server {
+	return &Server{writers: make(map[string]*rotate.RotateWriter)}
+}
+
+// Register expose writer under name, a writer already registered under name is replaced
+func (s *Server) Register(name string, writer *rotate.RotateWriter) error {
+	if len(name) == 0 {
+		return ErrWriterNameIsEmpty
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writers[name] = writer
+	return nil
+}
+
+// Unregister
+func (s *Server) Unregister(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.writers, name)
+}
+
+// ListWriters return the registered names sorted
+func (s *Server) ListWriters(_ context.Context, _ *ListWritersRequest) (*ListWritersResponse, error) {
+	s.mu.RLock()
+	names := make([]string, 0, len(s.writers))
+	for name := range s.writers {
+		names = append(names, name)
+	}
+	s.mu.RUnlock()
+	sort.Strings(names)
+	return &ListWritersResponse{Names: names}, nil
+}
+
+// Rotate force a rotation
+func (s *Server) Rotate(_ context.Context, req *RotateRequest) (*RotateResponse, error) {
+	writer, err := s.writer(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	backup, err := writer.Rotate()
+	if err != nil {
+		return nil, err
+	}
+	return &RotateResponse{Backup: backup}, nil
+}
+
+// GetStats
+func (s *Server) GetStats(_ context.Context, req *GetStatsRequest) (*GetStatsResponse, error) {
+	writer, err := s.writer(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	stats := writer.Stats()
+	resp := &GetStatsResponse{
+		BytesWritten:          stats.BytesWritten,
+		Writes:                stats.Writes,
+		Rotations:             stats.Rotations,
+		Compressions:          stats.Compressions,
+		CompressDurationNanos: int64(stats.CompressDuration),
+		DeletedBackups:        stats.DeletedBackups,
+		Dropped:               stats.Dropped,
+		Suppressed:            stats.Suppressed,
+		QueueDepth:            stats.QueueDepth,
+	}
+	if stats.LastError != nil {
+		resp.LastError = stats.LastError.Error()
+	}
+	return resp, nil
+}
+
+// SetRetention change the backup limits, see rotate.RotateWriter.SetRetention
+func (s *Server) SetRetention(_ context.Context, req *SetRetentionRequest) (*SetRetentionResponse, error) {
+	writer, err := s.writer(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	writer.SetRetention(req.MaxBackups, req.MaxDays)
+	return &SetRetentionResponse{}, nil
+}
+
+// writer
+func (s *Server) writer(name string) (*rotate.RotateWriter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	writer, ok := s.writers[name]
+	if !ok {
+		return nil, ErrWriterNotFound
+	}
+	return writer, nil
+}
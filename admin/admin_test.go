@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"context"
+	"github.com/AlfredAlan/rotate"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := rotate.NewRotateWriter(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	s := NewServer()
+	if err := s.Register("app", writer); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Register("", writer); err != ErrWriterNameIsEmpty {
+		t.Errorf("empty name incorrect, got:%v", err)
+	}
+
+	ctx := context.Background()
+	list, err := s.ListWriters(ctx, &ListWritersRequest{})
+	if err != nil || !reflect.DeepEqual(list.Names, []string{"app"}) {
+		t.Errorf("list incorrect, got:%+v %v", list, err)
+	}
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Rotate(ctx, &RotateRequest{Name: "app"}); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := s.GetStats(ctx, &GetStatsRequest{Name: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Writes != 1 || stats.BytesWritten != 5 || stats.Rotations != 1 {
+		t.Errorf("stats incorrect, got:%+v", stats)
+	}
+	if _, err := s.SetRetention(ctx, &SetRetentionRequest{Name: "app", MaxBackups: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Unregister("app")
+	if _, err := s.Rotate(ctx, &RotateRequest{Name: "app"}); err != ErrWriterNotFound {
+		t.Errorf("unregistered writer incorrect, got:%v", err)
+	}
+}
@@ -0,0 +1,551 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: admin/adminpb/admin.proto
+
+package adminpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListWritersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWritersRequest) Reset() {
+	*x = ListWritersRequest{}
+	mi := &file_admin_adminpb_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWritersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWritersRequest) ProtoMessage() {}
+
+func (x *ListWritersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_adminpb_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWritersRequest.ProtoReflect.Descriptor instead.
+func (*ListWritersRequest) Descriptor() ([]byte, []int) {
+	return file_admin_adminpb_admin_proto_rawDescGZIP(), []int{0}
+}
+
+type ListWritersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Names         []string               `protobuf:"bytes,1,rep,name=names,proto3" json:"names,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWritersResponse) Reset() {
+	*x = ListWritersResponse{}
+	mi := &file_admin_adminpb_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWritersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWritersResponse) ProtoMessage() {}
+
+func (x *ListWritersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_adminpb_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWritersResponse.ProtoReflect.Descriptor instead.
+func (*ListWritersResponse) Descriptor() ([]byte, []int) {
+	return file_admin_adminpb_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListWritersResponse) GetNames() []string {
+	if x != nil {
+		return x.Names
+	}
+	return nil
+}
+
+type RotateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateRequest) Reset() {
+	*x = RotateRequest{}
+	mi := &file_admin_adminpb_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateRequest) ProtoMessage() {}
+
+func (x *RotateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_adminpb_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateRequest.ProtoReflect.Descriptor instead.
+func (*RotateRequest) Descriptor() ([]byte, []int) {
+	return file_admin_adminpb_admin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RotateRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type RotateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Backup        string                 `protobuf:"bytes,1,opt,name=backup,proto3" json:"backup,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateResponse) Reset() {
+	*x = RotateResponse{}
+	mi := &file_admin_adminpb_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateResponse) ProtoMessage() {}
+
+func (x *RotateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_adminpb_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateResponse.ProtoReflect.Descriptor instead.
+func (*RotateResponse) Descriptor() ([]byte, []int) {
+	return file_admin_adminpb_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RotateResponse) GetBackup() string {
+	if x != nil {
+		return x.Backup
+	}
+	return ""
+}
+
+type GetStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsRequest) Reset() {
+	*x = GetStatsRequest{}
+	mi := &file_admin_adminpb_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsRequest) ProtoMessage() {}
+
+func (x *GetStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_adminpb_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetStatsRequest) Descriptor() ([]byte, []int) {
+	return file_admin_adminpb_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetStatsRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type GetStatsResponse struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	BytesWritten          int64                  `protobuf:"varint,1,opt,name=bytes_written,json=bytesWritten,proto3" json:"bytes_written,omitempty"`
+	Writes                int64                  `protobuf:"varint,2,opt,name=writes,proto3" json:"writes,omitempty"`
+	Rotations             int64                  `protobuf:"varint,3,opt,name=rotations,proto3" json:"rotations,omitempty"`
+	Compressions          int64                  `protobuf:"varint,4,opt,name=compressions,proto3" json:"compressions,omitempty"`
+	CompressDurationNanos int64                  `protobuf:"varint,5,opt,name=compress_duration_nanos,json=compressDurationNanos,proto3" json:"compress_duration_nanos,omitempty"`
+	DeletedBackups        int64                  `protobuf:"varint,6,opt,name=deleted_backups,json=deletedBackups,proto3" json:"deleted_backups,omitempty"`
+	Dropped               int64                  `protobuf:"varint,7,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	Suppressed            int64                  `protobuf:"varint,8,opt,name=suppressed,proto3" json:"suppressed,omitempty"`
+	QueueDepth            int64                  `protobuf:"varint,9,opt,name=queue_depth,json=queueDepth,proto3" json:"queue_depth,omitempty"`
+	LastError             string                 `protobuf:"bytes,10,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *GetStatsResponse) Reset() {
+	*x = GetStatsResponse{}
+	mi := &file_admin_adminpb_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsResponse) ProtoMessage() {}
+
+func (x *GetStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_adminpb_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetStatsResponse) Descriptor() ([]byte, []int) {
+	return file_admin_adminpb_admin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetStatsResponse) GetBytesWritten() int64 {
+	if x != nil {
+		return x.BytesWritten
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetWrites() int64 {
+	if x != nil {
+		return x.Writes
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetRotations() int64 {
+	if x != nil {
+		return x.Rotations
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetCompressions() int64 {
+	if x != nil {
+		return x.Compressions
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetCompressDurationNanos() int64 {
+	if x != nil {
+		return x.CompressDurationNanos
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetDeletedBackups() int64 {
+	if x != nil {
+		return x.DeletedBackups
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetDropped() int64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetSuppressed() int64 {
+	if x != nil {
+		return x.Suppressed
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetQueueDepth() int64 {
+	if x != nil {
+		return x.QueueDepth
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+type SetRetentionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	MaxBackups    int64                  `protobuf:"varint,2,opt,name=max_backups,json=maxBackups,proto3" json:"max_backups,omitempty"`
+	MaxDays       int64                  `protobuf:"varint,3,opt,name=max_days,json=maxDays,proto3" json:"max_days,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRetentionRequest) Reset() {
+	*x = SetRetentionRequest{}
+	mi := &file_admin_adminpb_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRetentionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRetentionRequest) ProtoMessage() {}
+
+func (x *SetRetentionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_adminpb_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRetentionRequest.ProtoReflect.Descriptor instead.
+func (*SetRetentionRequest) Descriptor() ([]byte, []int) {
+	return file_admin_adminpb_admin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SetRetentionRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SetRetentionRequest) GetMaxBackups() int64 {
+	if x != nil {
+		return x.MaxBackups
+	}
+	return 0
+}
+
+func (x *SetRetentionRequest) GetMaxDays() int64 {
+	if x != nil {
+		return x.MaxDays
+	}
+	return 0
+}
+
+type SetRetentionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRetentionResponse) Reset() {
+	*x = SetRetentionResponse{}
+	mi := &file_admin_adminpb_admin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRetentionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRetentionResponse) ProtoMessage() {}
+
+func (x *SetRetentionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_adminpb_admin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRetentionResponse.ProtoReflect.Descriptor instead.
+func (*SetRetentionResponse) Descriptor() ([]byte, []int) {
+	return file_admin_adminpb_admin_proto_rawDescGZIP(), []int{7}
+}
+
+var File_admin_adminpb_admin_proto protoreflect.FileDescriptor
+
+const file_admin_adminpb_admin_proto_rawDesc = "" +
+	"\n" +
+	"\x19admin/adminpb/admin.proto\x12\x0frotate.admin.v1\"\x14\n" +
+	"\x12ListWritersRequest\"+\n" +
+	"\x13ListWritersResponse\x12\x14\n" +
+	"\x05names\x18\x01 \x03(\tR\x05names\"#\n" +
+	"\rRotateRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"(\n" +
+	"\x0eRotateResponse\x12\x16\n" +
+	"\x06backup\x18\x01 \x01(\tR\x06backup\"%\n" +
+	"\x0fGetStatsRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\xec\x02\n" +
+	"\x10GetStatsResponse\x12#\n" +
+	"\rbytes_written\x18\x01 \x01(\x03R\fbytesWritten\x12\x16\n" +
+	"\x06writes\x18\x02 \x01(\x03R\x06writes\x12\x1c\n" +
+	"\trotations\x18\x03 \x01(\x03R\trotations\x12\"\n" +
+	"\fcompressions\x18\x04 \x01(\x03R\fcompressions\x126\n" +
+	"\x17compress_duration_nanos\x18\x05 \x01(\x03R\x15compressDurationNanos\x12'\n" +
+	"\x0fdeleted_backups\x18\x06 \x01(\x03R\x0edeletedBackups\x12\x18\n" +
+	"\adropped\x18\a \x01(\x03R\adropped\x12\x1e\n" +
+	"\n" +
+	"suppressed\x18\b \x01(\x03R\n" +
+	"suppressed\x12\x1f\n" +
+	"\vqueue_depth\x18\t \x01(\x03R\n" +
+	"queueDepth\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\n" +
+	" \x01(\tR\tlastError\"e\n" +
+	"\x13SetRetentionRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1f\n" +
+	"\vmax_backups\x18\x02 \x01(\x03R\n" +
+	"maxBackups\x12\x19\n" +
+	"\bmax_days\x18\x03 \x01(\x03R\amaxDays\"\x16\n" +
+	"\x14SetRetentionResponse2\xda\x02\n" +
+	"\x05Admin\x12X\n" +
+	"\vListWriters\x12#.rotate.admin.v1.ListWritersRequest\x1a$.rotate.admin.v1.ListWritersResponse\x12I\n" +
+	"\x06Rotate\x12\x1e.rotate.admin.v1.RotateRequest\x1a\x1f.rotate.admin.v1.RotateResponse\x12O\n" +
+	"\bGetStats\x12 .rotate.admin.v1.GetStatsRequest\x1a!.rotate.admin.v1.GetStatsResponse\x12[\n" +
+	"\fSetRetention\x12$.rotate.admin.v1.SetRetentionRequest\x1a%.rotate.admin.v1.SetRetentionResponseB,Z*github.com/AlfredAlan/rotate/admin/adminpbb\x06proto3"
+
+var (
+	file_admin_adminpb_admin_proto_rawDescOnce sync.Once
+	file_admin_adminpb_admin_proto_rawDescData []byte
+)
+
+func file_admin_adminpb_admin_proto_rawDescGZIP() []byte {
+	file_admin_adminpb_admin_proto_rawDescOnce.Do(func() {
+		file_admin_adminpb_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_admin_adminpb_admin_proto_rawDesc), len(file_admin_adminpb_admin_proto_rawDesc)))
+	})
+	return file_admin_adminpb_admin_proto_rawDescData
+}
+
+var file_admin_adminpb_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_admin_adminpb_admin_proto_goTypes = []any{
+	(*ListWritersRequest)(nil),   // 0: rotate.admin.v1.ListWritersRequest
+	(*ListWritersResponse)(nil),  // 1: rotate.admin.v1.ListWritersResponse
+	(*RotateRequest)(nil),        // 2: rotate.admin.v1.RotateRequest
+	(*RotateResponse)(nil),       // 3: rotate.admin.v1.RotateResponse
+	(*GetStatsRequest)(nil),      // 4: rotate.admin.v1.GetStatsRequest
+	(*GetStatsResponse)(nil),     // 5: rotate.admin.v1.GetStatsResponse
+	(*SetRetentionRequest)(nil),  // 6: rotate.admin.v1.SetRetentionRequest
+	(*SetRetentionResponse)(nil), // 7: rotate.admin.v1.SetRetentionResponse
+}
+var file_admin_adminpb_admin_proto_depIdxs = []int32{
+	0, // 0: rotate.admin.v1.Admin.ListWriters:input_type -> rotate.admin.v1.ListWritersRequest
+	2, // 1: rotate.admin.v1.Admin.Rotate:input_type -> rotate.admin.v1.RotateRequest
+	4, // 2: rotate.admin.v1.Admin.GetStats:input_type -> rotate.admin.v1.GetStatsRequest
+	6, // 3: rotate.admin.v1.Admin.SetRetention:input_type -> rotate.admin.v1.SetRetentionRequest
+	1, // 4: rotate.admin.v1.Admin.ListWriters:output_type -> rotate.admin.v1.ListWritersResponse
+	3, // 5: rotate.admin.v1.Admin.Rotate:output_type -> rotate.admin.v1.RotateResponse
+	5, // 6: rotate.admin.v1.Admin.GetStats:output_type -> rotate.admin.v1.GetStatsResponse
+	7, // 7: rotate.admin.v1.Admin.SetRetention:output_type -> rotate.admin.v1.SetRetentionResponse
+	4, // [4:8] is the sub-list for method output_type
+	0, // [0:4] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_admin_adminpb_admin_proto_init() }
+func file_admin_adminpb_admin_proto_init() {
+	if File_admin_adminpb_admin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_admin_adminpb_admin_proto_rawDesc), len(file_admin_adminpb_admin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_admin_adminpb_admin_proto_goTypes,
+		DependencyIndexes: file_admin_adminpb_admin_proto_depIdxs,
+		MessageInfos:      file_admin_adminpb_admin_proto_msgTypes,
+	}.Build()
+	File_admin_adminpb_admin_proto = out.File
+	file_admin_adminpb_admin_proto_goTypes = nil
+	file_admin_adminpb_admin_proto_depIdxs = nil
+}
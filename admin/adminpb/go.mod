@@ -0,0 +1,20 @@
+module github.com/AlfredAlan/rotate/admin/adminpb
+
+go 1.24.0
+
+require (
+	github.com/AlfredAlan/rotate v0.0.0-00010101000000-000000000000
+	google.golang.org/grpc v1.79.3
+	google.golang.org/protobuf v1.36.10
+)
+
+require (
+	go.uber.org/atomic v1.9.0 // indirect
+	go.uber.org/multierr v1.7.0 // indirect
+	golang.org/x/net v0.48.0 // indirect
+	golang.org/x/sys v0.39.0 // indirect
+	golang.org/x/text v0.32.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20251202230838-ff82c1b0f217 // indirect
+)
+
+replace github.com/AlfredAlan/rotate => ../..
@@ -0,0 +1,88 @@
+// Package adminpb serve an admin.Server over gRPC, Register add the Admin service of admin.proto
+// to a grpc.Server. It is a module of its own so the rotate module does not depend on gRPC.
+package adminpb
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative admin/adminpb/admin.proto
+
+import (
+	"context"
+	"errors"
+	"github.com/AlfredAlan/rotate/admin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// service serve the Admin rpcs with an admin.Server
+type service struct {
+	UnimplementedAdminServer
+	srv *admin.Server
+}
+
+// Register expose the writers of srv as the Admin service of s, e.g. a *grpc.Server
+func Register(s grpc.ServiceRegistrar, srv *admin.Server) {
+	RegisterAdminServer(s, &service{srv: srv})
+}
+
+// ListWriters
+func (s *service) ListWriters(ctx context.Context, _ *ListWritersRequest) (*ListWritersResponse, error) {
+	resp, err := s.srv.ListWriters(ctx, &admin.ListWritersRequest{})
+	if err != nil {
+		return nil, statusErr(err)
+	}
+	return &ListWritersResponse{Names: resp.Names}, nil
+}
+
+// Rotate
+func (s *service) Rotate(ctx context.Context, req *RotateRequest) (*RotateResponse, error) {
+	resp, err := s.srv.Rotate(ctx, &admin.RotateRequest{Name: req.GetName()})
+	if err != nil {
+		return nil, statusErr(err)
+	}
+	return &RotateResponse{Backup: resp.Backup}, nil
+}
+
+// GetStats
+func (s *service) GetStats(ctx context.Context, req *GetStatsRequest) (*GetStatsResponse, error) {
+	resp, err := s.srv.GetStats(ctx, &admin.GetStatsRequest{Name: req.GetName()})
+	if err != nil {
+		return nil, statusErr(err)
+	}
+	return &GetStatsResponse{
+		BytesWritten:          resp.BytesWritten,
+		Writes:                resp.Writes,
+		Rotations:             resp.Rotations,
+		Compressions:          resp.Compressions,
+		CompressDurationNanos: resp.CompressDurationNanos,
+		DeletedBackups:        resp.DeletedBackups,
+		Dropped:               resp.Dropped,
+		Suppressed:            resp.Suppressed,
+		QueueDepth:            resp.QueueDepth,
+		LastError:             resp.LastError,
+	}, nil
+}
+
+// SetRetention
+func (s *service) SetRetention(ctx context.Context, req *SetRetentionRequest) (*SetRetentionResponse, error) {
+	_, err := s.srv.SetRetention(ctx, &admin.SetRetentionRequest{
+		Name:       req.GetName(),
+		MaxBackups: req.GetMaxBackups(),
+		MaxDays:    req.GetMaxDays(),
+	})
+	if err != nil {
+		return nil, statusErr(err)
+	}
+	return &SetRetentionResponse{}, nil
+}
+
+// statusErr give the errors of admin.Server their gRPC code
+func statusErr(err error) error {
+	switch {
+	case errors.Is(err, admin.ErrWriterNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, admin.ErrWriterNameIsEmpty):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,80 @@
+package adminpb
+
+import (
+	"context"
+	"github.com/AlfredAlan/rotate"
+	"github.com/AlfredAlan/rotate/admin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "adminpb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := rotate.NewRotateWriter(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	srv := admin.NewServer()
+	if err := srv.Register("app", writer); err != nil {
+		t.Fatal(err)
+	}
+
+	l := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	Register(s, srv)
+	go func() {
+		_ = s.Serve(l)
+	}()
+	defer s.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := NewAdminClient(conn)
+
+	ctx := context.Background()
+	list, err := client.ListWriters(ctx, &ListWritersRequest{})
+	if err != nil || !reflect.DeepEqual(list.GetNames(), []string{"app"}) {
+		t.Errorf("list incorrect, got:%v %v", list, err)
+	}
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := client.Rotate(ctx, &RotateRequest{Name: "app"})
+	if err != nil || len(rotated.GetBackup()) == 0 {
+		t.Fatalf("rotate incorrect, got:%v %v", rotated, err)
+	}
+	stats, err := client.GetStats(ctx, &GetStatsRequest{Name: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.GetWrites() != 1 || stats.GetBytesWritten() != 5 || stats.GetRotations() != 1 {
+		t.Errorf("stats incorrect, got:%v", stats)
+	}
+	if _, err := client.SetRetention(ctx, &SetRetentionRequest{Name: "app", MaxBackups: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Rotate(ctx, &RotateRequest{Name: "other"}); status.Code(err) != codes.NotFound {
+		t.Errorf("unknown writer incorrect, got:%v", err)
+	}
+}
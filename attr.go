@@ -0,0 +1,57 @@
+package rotate
+
+import (
+	"errors"
+	"os"
+)
+
+// BackupAttr is a file attribute protecting finished backups from modification
+type BackupAttr int
+
+const (
+	// AttrNone leave backups as they are
+	AttrNone BackupAttr = iota
+	// AttrAppendOnly set the append-only flag, chattr +a
+	AttrAppendOnly
+	// AttrImmutable set the immutable flag, chattr +i
+	AttrImmutable
+)
+
+var ErrAttrUnsupported = errors.New("error: file attributes are not supported on this platform")
+
+// WithBackupAttr set attr on backups once they are finished, it requires CAP_LINUX_IMMUTABLE
+// and is only supported on Linux. Retention clears the flag before removing a backup.
+func WithBackupAttr(attr BackupAttr) RotateOption {
+	return func(o *rotateOption) {
+		o.backupAttr = attr
+	}
+}
+
+// protectBackup set the backup attribute on name
+func (r *RotateWriter) protectBackup(name string) error {
+	if r.opt.backupAttr == AttrNone {
+		return nil
+	}
+	return setBackupAttr(name, r.opt.backupAttr, true)
+}
+
+// removeBackup remove name and its sidecar, clearing the backup attribute first
+func (r *RotateWriter) removeBackup(name string) error {
+	if r.opt.backupAttr != AttrNone {
+		if err := setBackupAttr(name, r.opt.backupAttr, false); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Remove(name); err != nil {
+		return err
+	}
+	if err := r.indexRemove(name); err != nil {
+		return err
+	}
+	if r.opt.sidecar {
+		if err := os.Remove(sidecarName(name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+//go:build linux
+// +build linux
+
+package rotate
+
+import (
+	"go.uber.org/multierr"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	fsAppendFl    = 0x00000020
+	fsImmutableFl = 0x00000010
+)
+
+var (
+	// _IOR('f', 1, long) and _IOW('f', 2, long)
+	fsIocGetFlags = uintptr(2<<30 | unsafe.Sizeof(uintptr(0))<<16 | 'f'<<8 | 1)
+	fsIocSetFlags = uintptr(1<<30 | unsafe.Sizeof(uintptr(0))<<16 | 'f'<<8 | 2)
+)
+
+// setBackupAttr set or clear attr on name like chattr
+func setBackupAttr(name string, attr BackupAttr, set bool) (err error) {
+	fp, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Append(err, fp.Close())
+	}()
+
+	flag := int32(fsAppendFl)
+	if attr == AttrImmutable {
+		flag = fsImmutableFl
+	}
+	var flags int32
+	if err = ioctl(fp.Fd(), fsIocGetFlags, &flags); err != nil {
+		return err
+	}
+	if set {
+		flags |= flag
+	} else {
+		flags &^= flag
+	}
+	return ioctl(fp.Fd(), fsIocSetFlags, &flags)
+}
+
+// ioctl
+func ioctl(fd, req uintptr, flags *int32) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(unsafe.Pointer(flags))); errno != 0 {
+		return &os.PathError{Op: "ioctl", Path: "", Err: errno}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotateWriter_WithBackupAttr(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "attr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	probe := filepath.Join(dir, "probe")
+	if err := ioutil.WriteFile(probe, nil, defaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := setBackupAttr(probe, AttrImmutable, true); err != nil {
+		t.Skipf("file attributes unavailable: %v", err)
+	}
+	if err := setBackupAttr(probe, AttrImmutable, false); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithBackupAttr(AttrImmutable), WithMaxBackups(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backupName := writer.backupName
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(backupName); err == nil {
+		t.Error("immutable backup should not be removable")
+	}
+	if err := writer.removeFiles([]string{backupName}); err != nil {
+		t.Errorf("retention should clear the flag, got:%v", err)
+	}
+}
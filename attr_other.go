@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package rotate
+
+// setBackupAttr
+func setBackupAttr(name string, attr BackupAttr, set bool) error {
+	return ErrAttrUnsupported
+}
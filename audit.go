@@ -0,0 +1,229 @@
+package rotate
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go.uber.org/multierr"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	chainStartPrefix = "#chain-start "
+	chainEndPrefix   = "#chain-end "
+)
+
+var (
+	ErrChainBroken    = errors.New("error: hash chain broken")
+	ErrChainTruncated = errors.New("error: hash chain truncated")
+)
+
+type (
+	// Chain is the hash chain checkpoint of a file, Start is the End of the previous file
+	// so a missing backup shows as a gap between two files
+	Chain struct {
+		Start   string // hex hash the file continues from
+		End     string // hex hash of the last record
+		Records int64
+	}
+
+	// chainState is the rolling hash of the active file, every line is a record and its hash
+	// is H(hash of the previous record || line)
+	chainState struct {
+		start   []byte
+		prev    []byte
+		records int64
+		line    hash.Hash // hash of the record in progress, nil between records
+		raw     bool      // writing the chain lines themselves
+	}
+)
+
+// WithAuditChain chain the hash of every line to the previous one, the active file starts with
+// the hash it continues from and ends with a checkpoint of the chain written at rotation,
+// see VerifyChain. Without a key anyone able to rewrite a file can rebuild its chain,
+// with a key the hashes are HMAC-SHA256 and only the key holders can.
+func WithAuditChain(key []byte) RotateOption {
+	return func(o *rotateOption) {
+		o.audit = true
+		o.auditKey = key
+	}
+}
+
+// newChainHash
+func newChainHash(key []byte) hash.Hash {
+	if len(key) == 0 {
+		return sha256.New()
+	}
+	return hmac.New(sha256.New, key)
+}
+
+// add hash the records of data, a line split across writes is one record
+func (c *chainState) add(key, data []byte) {
+	for len(data) > 0 {
+		if c.line == nil {
+			c.line = newChainHash(key)
+			c.line.Write(c.prev)
+		}
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			c.line.Write(data)
+			return
+		}
+		c.line.Write(data[:i+1])
+		c.prev = c.line.Sum(nil)
+		c.line = nil
+		c.records++
+		data = data[i+1:]
+	}
+}
+
+// writeRaw write line without hashing it
+func (r *RotateWriter) writeRaw(line string) error {
+	r.hashChain.raw = true
+	defer func() {
+		r.hashChain.raw = false
+	}()
+	return r.writeFile([]byte(line))
+}
+
+// writeChainStart write the hash the new active file continues from
+func (r *RotateWriter) writeChainStart() error {
+	if !r.opt.audit {
+		return nil
+	}
+	if r.hashChain.prev == nil {
+		r.hashChain.prev = make([]byte, sha256.Size)
+	}
+	r.hashChain.start = r.hashChain.prev
+	r.hashChain.records = 0
+	r.hashChain.line = nil
+	return r.writeRaw(chainStartPrefix + hex.EncodeToString(r.hashChain.start) + "\n")
+}
+
+// writeChainEnd write the chain checkpoint of the active file, a pending partial line is
+// terminated first
+func (r *RotateWriter) writeChainEnd() error {
+	if !r.opt.audit || r.hashChain.start == nil {
+		return nil
+	}
+	if r.hashChain.line != nil {
+		if err := r.writeFile([]byte{'\n'}); err != nil {
+			return err
+		}
+	}
+	return r.writeRaw(fmt.Sprintf("%s%s %d\n", chainEndPrefix, hex.EncodeToString(r.hashChain.prev), r.hashChain.records))
+}
+
+// restoreChain rebuild the chain of an existing active file
+func (r *RotateWriter) restoreChain() error {
+	if !r.opt.audit {
+		return nil
+	}
+	fp, err := os.Open(r.filename)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	c, err := readChain(fp, r.opt.auditKey, false)
+	if errors.Is(err, ErrChainBroken) {
+		// written before the audit mode, the next file starts a new chain
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	r.hashChain = c
+	return nil
+}
+
+// VerifyChain check the hash chain of a backup written WithAuditChain(key), compressed with gzip
+// or not. It return ErrChainBroken if a record was changed, inserted or removed and
+// ErrChainTruncated if the chain checkpoint is missing.
+func VerifyChain(backup string, key []byte) (Chain, error) {
+	fp, err := os.Open(backup)
+	if err != nil {
+		return Chain{}, err
+	}
+	defer fp.Close()
+	var rd io.Reader = fp
+	if strings.HasSuffix(backup, ".gz") {
+		gz, err := gzip.NewReader(fp)
+		if err != nil {
+			return Chain{}, err
+		}
+		defer gz.Close()
+		rd = gz
+	}
+	c, err := readChain(rd, key, true)
+	if err != nil {
+		return Chain{}, err
+	}
+	return Chain{Start: hex.EncodeToString(c.start), End: hex.EncodeToString(c.prev), Records: c.records}, nil
+}
+
+// readChain hash the records of a chained file, the checkpoint is the last line of a complete file
+func readChain(rd io.Reader, key []byte, complete bool) (c chainState, err error) {
+	br := bufio.NewReader(rd)
+	first, err := br.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return c, err
+	}
+	if !strings.HasPrefix(first, chainStartPrefix) {
+		return c, fmt.Errorf("%w: missing chain start", ErrChainBroken)
+	}
+	if c.start, err = hex.DecodeString(strings.TrimSpace(strings.TrimPrefix(first, chainStartPrefix))); err != nil {
+		return c, fmt.Errorf("%w: invalid chain start", ErrChainBroken)
+	}
+	c.prev = c.start
+	var pending []byte
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return c, err
+		}
+		if err == io.EOF {
+			if len(line) > 0 {
+				// a line without newline is a record in progress
+				if pending != nil {
+					c.add(key, pending)
+				}
+				pending = line
+			}
+			break
+		}
+		if pending != nil {
+			c.add(key, pending)
+		}
+		pending = line
+	}
+	if !complete {
+		if pending != nil {
+			c.add(key, pending)
+		}
+		return c, nil
+	}
+	if pending == nil || !bytes.HasPrefix(pending, []byte(chainEndPrefix)) {
+		return c, ErrChainTruncated
+	}
+	fields := strings.Fields(strings.TrimPrefix(string(pending), chainEndPrefix))
+	if len(fields) != 2 {
+		return c, fmt.Errorf("%w: invalid chain end", ErrChainBroken)
+	}
+	records, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return c, multierr.Append(fmt.Errorf("%w: invalid chain end", ErrChainBroken), err)
+	}
+	if c.line != nil || fields[0] != hex.EncodeToString(c.prev) || records != c.records {
+		return c, fmt.Errorf("%w: checkpoint mismatch after %d records", ErrChainBroken, c.records)
+	}
+	return c, nil
+}
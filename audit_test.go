@@ -0,0 +1,117 @@
+package rotate
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_WithAuditChain(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key := []byte("secret")
+	filename := filepath.Join(dir, "app.log")
+	writer, err := NewRotateWriter(filename, WithAuditChain(key), WithGzip(true),
+		WithClock(&stepClock{now: time.Now()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	var chains []Chain
+	for _, data := range []string{"first\nsec", "ond\n", "third"} {
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 3 {
+		t.Fatalf("backups incorrect, got:%v", backups)
+	}
+	for _, b := range backups {
+		chain, err := VerifyChain(b.Name, key)
+		if err != nil {
+			t.Fatalf("verify %s incorrect, got:%v", b.Name, err)
+		}
+		chains = append(chains, chain)
+	}
+	if chains[0].Records != 2 || chains[1].Records != 1 || chains[2].Records != 1 {
+		t.Errorf("records incorrect, got:%+v", chains)
+	}
+	for i := 1; i < len(chains); i++ {
+		if chains[i].Start != chains[i-1].End {
+			t.Errorf("link incorrect, got:%+v", chains)
+		}
+	}
+	if _, err := VerifyChain(backups[0].Name, []byte("other")); !errors.Is(err, ErrChainBroken) {
+		t.Errorf("wrong key incorrect, got:%v", err)
+	}
+}
+
+func TestVerifyChain_Tampered(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "app.log")
+	writer, err := NewRotateWriter(filename, WithAuditChain(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte("a\nb\nc\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(backups[0].Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyChain(backups[0].Name, nil); err != nil {
+		t.Fatalf("verify incorrect, got:%v", err)
+	}
+
+	tampered := filepath.Join(dir, "tampered.log")
+	if err := ioutil.WriteFile(tampered, []byte(strings.Replace(string(data), "b\n", "x\n", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyChain(tampered, nil); !errors.Is(err, ErrChainBroken) {
+		t.Errorf("tampered incorrect, got:%v", err)
+	}
+	truncated := filepath.Join(dir, "truncated.log")
+	if err := ioutil.WriteFile(truncated, data[:strings.Index(string(data), "c\n")], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyChain(truncated, nil); !errors.Is(err, ErrChainTruncated) {
+		t.Errorf("truncated incorrect, got:%v", err)
+	}
+}
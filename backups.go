@@ -0,0 +1,107 @@
+package rotate
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackupInfo describe a backup of the writer
+type BackupInfo struct {
+	Name       string
+	Size       int64
+	ModTime    time.Time
+	Compressed bool
+	Timestamp  time.Time // parsed from the name, zero if the name does not match the time format
+	Removed    bool      // only set by Query, the backup was removed or shipped
+}
+
+// Backups return compressed and uncompressed backups ordered by timestamp,
+// or by modification time for names without a timestamp
+func (r *RotateWriter) Backups() ([]BackupInfo, error) {
+	files, err := r.allBackupFiles()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]BackupInfo, 0, len(files))
+	for _, file := range files {
+		fi, err := os.Stat(file)
+		if os.IsNotExist(err) {
+			// removed by the background cleanup meanwhile
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		ts, _ := r.parseBackupTime(file)
+		infos = append(infos, BackupInfo{
+			Name:       file,
+			Size:       fi.Size(),
+			ModTime:    fi.ModTime(),
+			Compressed: strings.HasSuffix(file, ".gz") || r.recompressed(file),
+			Timestamp:  ts,
+		})
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		ti, tj := infos[i].sortTime(), infos[j].sortTime()
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		if ni, nj := r.backupIndex(infos[i].Name), r.backupIndex(infos[j].Name); ni != nj {
+			return ni < nj
+		}
+		return infos[i].Name < infos[j].Name
+	})
+	return infos, nil
+}
+
+// trimBackupExt drop the compression extension and ext of a backup name
+func (r *RotateWriter) trimBackupExt(name, ext string) string {
+	trimmed := strings.TrimSuffix(name, ".gz")
+	if r.recompressed(name) {
+		trimmed = strings.TrimSuffix(name, r.opt.recompress.Ext())
+	}
+	return strings.TrimSuffix(trimmed, ext)
+}
+
+// sortTime
+func (b BackupInfo) sortTime() time.Time {
+	if b.Timestamp.IsZero() {
+		return b.ModTime
+	}
+	return b.Timestamp
+}
+
+// allBackupFiles return compressed and uncompressed backups
+func (r *RotateWriter) allBackupFiles() ([]string, error) {
+	if r.customBackupPattern() {
+		return r.matchBackups()
+	}
+	files := make([]string, 0)
+	for _, compressed := range []bool{false, true} {
+		matches, err := filepath.Glob(r.backupPattern(compressed))
+		if err != nil {
+			return nil, err
+		}
+		if _, _, ext := r.paths(); !compressed && len(ext) == 0 {
+			// prefix-* also match compressed backups
+			matches = r.excludeCompressed(matches)
+		}
+		files = append(files, matches...)
+	}
+	return r.appendRecompressed(files)
+}
+
+// parseBackupTime parse the timestamp encoded in a backup name
+func (r *RotateWriter) parseBackupTime(name string) (time.Time, error) {
+	_, prefix, ext := r.paths()
+	date := strings.TrimPrefix(r.trimBackupExt(name, ext), prefix+r.opt.delimiter)
+	if r.opt.dateIndex {
+		date, _ = trimDateIndex(date)
+	}
+	if !r.opt.localTime {
+		return parseTime(r.opt.timeFormat, date, time.UTC)
+	}
+	return parseTime(r.opt.timeFormat, date, time.Local)
+}
@@ -0,0 +1,54 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_Backups(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(tmpFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	now := time.Now().Truncate(time.Second)
+	older := mockBackupName(tmpFileName, now.Add(-time.Hour).Format(writer.opt.timeFormat)) + ".gz"
+	newer := mockBackupName(tmpFileName, now.Format(writer.opt.timeFormat))
+	for _, name := range []string{newer, older} {
+		if err := ioutil.WriteFile(name, []byte("test"), defaultFilePerm); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(name)
+	}
+
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("backups count incorrect, got:%v", backups)
+	}
+	if backups[0].Name != older || !backups[0].Compressed || !backups[0].Timestamp.Equal(now.Add(-time.Hour)) {
+		t.Errorf("older backup incorrect, got:%+v", backups[0])
+	}
+	if backups[1].Name != newer || backups[1].Compressed || backups[1].Size != 4 || !backups[1].Timestamp.Equal(now) {
+		t.Errorf("newer backup incorrect, got:%+v", backups[1])
+	}
+}
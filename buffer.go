@@ -0,0 +1,87 @@
+package rotate
+
+import (
+	"time"
+)
+
+// minBufferSize is the smallest buffer worth keeping, lower write rates write through
+const minBufferSize = 4096
+
+// bufferState is guarded by the writer lock
+type bufferState struct {
+	data        []byte
+	window      int64 // bytes written since windowStart
+	windowStart time.Time
+	rate        float64 // bytes per second over the last window
+}
+
+// WithAdaptiveBuffer buffer writes according to the write rate: the buffer hold what is written
+// within delay at the rate of the last delay, up to max bytes, and is flushed at least every delay.
+// Below minBufferSize writes go straight to the file so tailers stay live at low volume.
+func WithAdaptiveBuffer(max int, delay time.Duration) RotateOption {
+	return func(o *rotateOption) {
+		o.bufferMax = max
+		o.bufferDelay = delay
+	}
+}
+
+// bufferWrite
+func (r *RotateWriter) bufferWrite(data []byte) error {
+	b := &r.buffer
+	now := r.now()
+	if b.windowStart.IsZero() {
+		b.windowStart = now
+	}
+	b.window += int64(len(data))
+	if elapsed := now.Sub(b.windowStart); elapsed >= r.opt.bufferDelay {
+		b.rate = float64(b.window) / elapsed.Seconds()
+		b.window, b.windowStart = 0, now
+	}
+
+	target := int(b.rate * r.opt.bufferDelay.Seconds())
+	if target > r.opt.bufferMax {
+		target = r.opt.bufferMax
+	}
+	if len(b.data)+len(data) > target || target < minBufferSize {
+		if err := r.flushBuffer(); err != nil {
+			return err
+		}
+	}
+	if len(data) >= target || target < minBufferSize {
+		_, err := r.fp.Write(data)
+		return err
+	}
+	b.data = append(b.data, data...)
+	return nil
+}
+
+// flushBuffer write the buffered data to the active file, the lock must be held
+func (r *RotateWriter) flushBuffer() error {
+	if len(r.buffer.data) == 0 {
+		return nil
+	}
+	_, err := r.fp.Write(r.buffer.data)
+	r.buffer.data = r.buffer.data[:0]
+	r.noteSize()
+	return err
+}
+
+// flushBufferOnInterval
+func (r *RotateWriter) flushBufferOnInterval() {
+	ticker := time.NewTicker(r.opt.bufferDelay)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.mu.Lock()
+			if !r.done.Load() {
+				if err := r.flushBuffer(); err != nil {
+					r.setErr(err)
+				}
+			}
+			r.mu.Unlock()
+		case <-r.postDone:
+			return
+		}
+	}
+}
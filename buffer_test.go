@@ -0,0 +1,135 @@
+package rotate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type manualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// Now
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Add
+func (c *manualClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestRotateWriter_WithAdaptiveBuffer(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "buffer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "app.log")
+	clock := &manualClock{now: time.Now()}
+	writer, err := NewRotateWriter(filename, WithAdaptiveBuffer(64*1024, time.Minute), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	fileSize := func() int64 {
+		fi, err := os.Stat(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fi.Size()
+	}
+
+	// low volume write through
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	if size := fileSize(); size != 5 {
+		t.Errorf("low volume write should not be buffered, got size:%d", size)
+	}
+
+	// a burst of 100KB per minute buffers up to 64KB
+	line := append(bytes.Repeat([]byte("x"), 1023), '\n')
+	for i := 0; i < 100; i++ {
+		if _, err := writer.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clock.Add(time.Minute)
+	before := fileSize()
+	if _, err := writer.Write(line); err != nil {
+		t.Fatal(err)
+	}
+	if size := fileSize(); size != before {
+		t.Errorf("burst write should be buffered, got size:%d want:%d", size, before)
+	}
+	if stats := writer.Stats(); stats.ActiveSize != 5+101*1024 || stats.ActiveDiskSize != before {
+		t.Errorf("buffered sizes incorrect, got:%d %d", stats.ActiveSize, stats.ActiveDiskSize)
+	}
+	if err := writer.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if size := fileSize(); size != 5+101*1024 {
+		t.Errorf("sync should flush the buffer, got size:%d", size)
+	}
+	if stats := writer.Stats(); stats.ActiveDiskSize != stats.ActiveSize {
+		t.Errorf("flushed sizes incorrect, got:%d %d", stats.ActiveSize, stats.ActiveDiskSize)
+	}
+}
+
+func TestRotateWriter_WithAdaptiveBuffer_MaxSize(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "buffer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clock := &manualClock{now: time.Now()}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithAdaptiveBuffer(64*1024, time.Minute),
+		WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	writer.opt.maxSize = 16 * 1024
+
+	// buffered bytes count toward maxSize so no backup outgrow it once flushed
+	line := append(bytes.Repeat([]byte("x"), 1023), '\n')
+	for i := 0; i < 100; i++ {
+		if i == 50 {
+			clock.Add(time.Minute)
+		}
+		if _, err := writer.Write(line); err != nil {
+			t.Fatal(err)
+		}
+		if stats := writer.Stats(); stats.ActiveSize > writer.opt.maxSize {
+			t.Fatalf("active size incorrect, got:%d", stats.ActiveSize)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) == 0 {
+		t.Fatal("backups incorrect, got none")
+	}
+	for _, b := range backups {
+		if b.Size > writer.opt.maxSize {
+			t.Errorf("backup size incorrect, got:%d", b.Size)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package rotate
+
+import (
+	"go.uber.org/multierr"
+	"sort"
+)
+
+// NewWriters open a writer per filename with its options, all or nothing: if one fails the writers
+// already opened are closed and the error returned
+func NewWriters(files map[string][]RotateOption) (map[string]*RotateWriter, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	// deterministic order, so the same setup fails on the same file
+	sort.Strings(names)
+
+	writers := make(map[string]*RotateWriter, len(files))
+	for _, name := range names {
+		w, err := NewRotateWriter(name, files[name]...)
+		if err != nil {
+			for _, opened := range writers {
+				err = multierr.Append(err, opened.Close())
+			}
+			return nil, err
+		}
+		writers[name] = w
+	}
+	return writers, nil
+}
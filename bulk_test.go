@@ -0,0 +1,63 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewWriters(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "writers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	access, app := filepath.Join(dir, "access.log"), filepath.Join(dir, "app.log")
+	writers, err := NewWriters(map[string][]RotateOption{
+		access: nil,
+		app:    {WithGzip(true)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(writers) != 2 || writers[access] == nil || !writers[app].opt.gzip {
+		t.Fatalf("writers incorrect, got:%v", writers)
+	}
+	for _, w := range writers {
+		if err := w.Close(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestNewWriters_failed(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "writers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// opened first, then closed once "b" fails
+	opened := filepath.Join(dir, "a.log")
+	blocker := filepath.Join(dir, "blocker")
+	if err := ioutil.WriteFile(blocker, nil, defaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	// the final metrics report of Close is the only one within the hour
+	sink := &mockSink{counters: map[string]int64{}, gauges: map[string]float64{}, timings: map[string]int{}}
+	writers, err := NewWriters(map[string][]RotateOption{
+		opened:                          {WithMetrics(sink, time.Hour)},
+		filepath.Join(blocker, "b.log"): nil,
+	})
+	if err == nil || writers != nil {
+		t.Fatalf("NewWriters should fail, got:%v %v", writers, err)
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if _, ok := sink.gauges["queue_depth"]; !ok {
+		t.Errorf("opened writer should be closed, got:%v", sink.gauges)
+	}
+}
@@ -0,0 +1,90 @@
+package rotate
+
+import (
+	"context"
+	"go.uber.org/multierr"
+	"io"
+	"sync"
+)
+
+// channelBufSize is the capacity of the channel of NewChannelWriter
+const channelBufSize = 256
+
+// channelWriter drain the records of a channel into a RotateWriter
+type channelWriter struct {
+	r    *RotateWriter
+	ch   chan []byte
+	done chan struct{}
+	once sync.Once
+	errs error
+}
+
+// NewChannelWriter return a channel whose records are written to filename by a goroutine owned by
+// the writer, producers never contend on the writer lock. The records queued while a write is in
+// progress are written as one batch, with WithSyncEveryWrite the batch is synced once. Errors
+// of records are returned by Close. Close the returned Closer, not the channel, once every
+// producer stopped sending.
+func NewChannelWriter(filename string, options ...RotateOption) (chan<- []byte, io.Closer, error) {
+	r, err := NewRotateWriter(filename, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := &channelWriter{r: r, ch: make(chan []byte, channelBufSize), done: make(chan struct{})}
+	go c.drain()
+	return c.ch, c, nil
+}
+
+// drain
+func (c *channelWriter) drain() {
+	defer close(c.done)
+	batch := make([][]byte, 0, channelBufSize)
+	for record := range c.ch {
+		batch = append(batch[:0], record)
+	collect:
+		for len(batch) < channelBufSize {
+			select {
+			case record, ok := <-c.ch:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, record)
+			default:
+				break collect
+			}
+		}
+		if err := c.r.writeBatch(batch); err != nil {
+			c.errs = multierr.Append(c.errs, err)
+		}
+	}
+}
+
+// Close stop accepting records, write the queued ones and close the writer
+func (c *channelWriter) Close() error {
+	c.once.Do(func() {
+		close(c.ch)
+		<-c.done
+		c.errs = multierr.Append(c.errs, c.r.Close())
+	})
+	return c.errs
+}
+
+// writeBatch write every record and sync them at once
+func (r *RotateWriter) writeBatch(records [][]byte) (errs error) {
+	ctx := context.Background()
+	var last uint64
+	for _, record := range records {
+		n, seq, err := r.appendData(ctx, record)
+		if r.mirror != nil {
+			_, err = r.writeMirror(ctx, record, n, err)
+		}
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+		last = seq
+	}
+	if r.opt.syncEveryWrite && last > 0 {
+		errs = multierr.Append(errs, r.commit(last))
+	}
+	return errs
+}
@@ -0,0 +1,52 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNewChannelWriter(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "channel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "app.log")
+	if _, _, err := NewChannelWriter(""); err != ErrFileNameIsEmpty {
+		t.Errorf("empty filename incorrect, got:%v", err)
+	}
+	ch, closer, err := NewChannelWriter(filename, WithSyncEveryWrite(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				ch <- []byte("test\n")
+			}
+		}()
+	}
+	wg.Wait()
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := closer.Close(); err != nil {
+		t.Errorf("second close incorrect, got:%v", err)
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(data), "test\n"); got != 400 {
+		t.Errorf("records incorrect, got:%d", got)
+	}
+}
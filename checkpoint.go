@@ -0,0 +1,183 @@
+package rotate
+
+import (
+	"encoding/json"
+	"errors"
+	"go.uber.org/multierr"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+var ErrCheckpointNotFound = errors.New("error: checkpoint file not found")
+
+type (
+	// Checkpoint is a consumer position in the writer output. ID is the backup name of the
+	// file without compression extension, so it stays valid when the active file is rotated
+	// or its backup compressed. Offset counts uncompressed bytes.
+	Checkpoint struct {
+		ID     string `json:"id"`
+		Offset int64  `json:"offset"`
+	}
+
+	// CheckpointReader read the writer output from a checkpoint and track the position
+	CheckpointReader struct {
+		r      *RotateWriter
+		ids    []string
+		names  []string
+		active *os.File // opened with the file list so the last file can't be missed
+		cur    io.ReadCloser
+		pos    Checkpoint
+	}
+)
+
+var _ io.ReadCloser = (*CheckpointReader)(nil)
+
+// LoadCheckpoint read a checkpoint saved by SaveCheckpoint, a missing file is the zero checkpoint
+func LoadCheckpoint(filename string) (Checkpoint, error) {
+	var c Checkpoint
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return c, nil
+	} else if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
+
+// SaveCheckpoint write c to filename atomically
+func SaveCheckpoint(filename string, c Checkpoint) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	tmp := filename + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, defaultFilePerm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// OpenCheckpoint return a reader starting at c, the zero checkpoint start at the oldest backup.
+// It return ErrCheckpointNotFound if the file of c was removed meanwhile. The reader return
+// io.EOF at the end of the active file, open a new one from Checkpoint to resume later.
+func (r *RotateWriter) OpenCheckpoint(c Checkpoint) (*CheckpointReader, error) {
+	r.mu.Lock()
+	backups, err := r.Backups()
+	if err != nil {
+		r.mu.Unlock()
+		return nil, err
+	}
+	filename, _, _ := r.paths()
+	active, err := os.Open(filename)
+	activeID := r.backupName
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	cr := &CheckpointReader{r: r, active: active}
+	seen := make(map[string]struct{}, len(backups))
+	for _, b := range backups {
+		id := r.checkpointID(b.Name)
+		if _, ok := seen[id]; ok {
+			// plain file kept next to the compressed one
+			continue
+		}
+		seen[id] = struct{}{}
+		cr.ids = append(cr.ids, id)
+		cr.names = append(cr.names, b.Name)
+	}
+	cr.ids = append(cr.ids, activeID)
+	cr.names = append(cr.names, filename)
+
+	if len(c.ID) > 0 {
+		i := 0
+		for i < len(cr.ids) && cr.ids[i] != c.ID {
+			i++
+		}
+		if i == len(cr.ids) {
+			return nil, multierr.Append(ErrCheckpointNotFound, active.Close())
+		}
+		cr.ids, cr.names = cr.ids[i:], cr.names[i:]
+	}
+	cr.pos = Checkpoint{ID: cr.ids[0], Offset: c.Offset}
+	if err = cr.open(); err != nil {
+		return nil, multierr.Append(err, cr.Close())
+	}
+	return cr, nil
+}
+
+// Checkpoint return the position after the last byte read
+func (c *CheckpointReader) Checkpoint() Checkpoint {
+	return c.pos
+}
+
+// Read
+func (c *CheckpointReader) Read(p []byte) (int, error) {
+	for {
+		n, err := c.cur.Read(p)
+		c.pos.Offset += int64(n)
+		if err != io.EOF || len(c.ids) == 1 {
+			return n, err
+		}
+		if n > 0 {
+			return n, nil
+		}
+		// next file
+		if err = c.cur.Close(); err != nil {
+			return 0, err
+		}
+		c.cur = nil
+		c.ids, c.names = c.ids[1:], c.names[1:]
+		c.pos = Checkpoint{ID: c.ids[0]}
+		if err = c.open(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Close
+func (c *CheckpointReader) Close() error {
+	var err error
+	if c.cur != nil {
+		err = c.cur.Close()
+	}
+	if c.active != nil && c.cur != io.ReadCloser(c.active) {
+		err = multierr.Append(err, c.active.Close())
+	}
+	c.cur, c.active = nil, nil
+	return err
+}
+
+// open the current file and skip to the offset
+func (c *CheckpointReader) open() error {
+	if len(c.ids) == 1 {
+		c.cur = c.active
+	} else {
+		fp, err := c.r.openBackup(c.names[0])
+		if err != nil {
+			return err
+		}
+		c.cur = fp
+	}
+	if c.pos.Offset == 0 {
+		return nil
+	}
+	if s, ok := c.cur.(io.Seeker); ok {
+		_, err := s.Seek(c.pos.Offset, io.SeekStart)
+		return err
+	}
+	_, err := io.CopyN(ioutil.Discard, c.cur, c.pos.Offset)
+	return err
+}
+
+// checkpointID is the backup name without compression extension
+func (r *RotateWriter) checkpointID(name string) string {
+	if r.recompressed(name) {
+		return strings.TrimSuffix(name, r.opt.recompress.Ext())
+	}
+	return strings.TrimSuffix(name, ".gz")
+}
@@ -0,0 +1,81 @@
+package rotate
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_OpenCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithClock(&stepClock{now: time.Now()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := writer.Write([]byte("first\nsecond\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := writer.OpenCheckpoint(Checkpoint{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 6)
+	if _, err := io.ReadFull(reader, buf); err != nil || string(buf) != "first\n" {
+		t.Fatalf("read incorrect, got:%q %v", buf, err)
+	}
+	saved := filepath.Join(dir, "consumer.json")
+	if err := SaveCheckpoint(saved, reader.Checkpoint()); err != nil {
+		t.Fatal(err)
+	}
+	if err := reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the file is rotated and compressed before the consumer resume
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte("third\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadCheckpoint(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader, err = writer.OpenCheckpoint(c); err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "second\nthird\n" {
+		t.Errorf("resume incorrect, got:%q", data)
+	}
+	if c := reader.Checkpoint(); c.ID != writer.backupName || c.Offset != 6 {
+		t.Errorf("checkpoint incorrect, got:%+v", c)
+	}
+
+	if _, err = writer.OpenCheckpoint(Checkpoint{ID: filepath.Join(dir, "gone.log")}); err != ErrCheckpointNotFound {
+		t.Errorf("removed file incorrect, got:%v", err)
+	}
+	if c, err = LoadCheckpoint(filepath.Join(dir, "missing.json")); err != nil || c != (Checkpoint{}) {
+		t.Errorf("missing checkpoint incorrect, got:%+v %v", c, err)
+	}
+}
@@ -0,0 +1,194 @@
+package rotate
+
+import (
+	"go.uber.org/multierr"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithCleanupRate remove at most batch backups every interval with workers concurrent deletions,
+// so a large retention cleanup does not hammer the filesystem, zero batch means no limit. Rate
+// limited deletions run on their own goroutine, Wait covers them.
+func WithCleanupRate(batch int, interval time.Duration, workers int) RotateOption {
+	return func(o *rotateOption) {
+		o.cleanupBatch = batch
+		o.cleanupInterval = interval
+		o.cleanupWorkers = workers
+	}
+}
+
+// removalQueue hold the files waiting for a rate limited removal, see WithCleanupRate
+type removalQueue struct {
+	mu     sync.Mutex
+	files  []string
+	queued map[string]bool
+	busy   bool // a task is counted for Wait until the queue drains
+	wake   chan struct{}
+}
+
+// removeFiles delete files, with a cleanup rate they are queued to the cleanup goroutine so the
+// worker and the write path never sleep. Directories left empty below the active file directory
+// are removed too.
+func (r *RotateWriter) removeFiles(files []string) error {
+	if r.opt.cleanupBatch > 0 {
+		r.queueRemoval(files)
+		return nil
+	}
+	return r.removeNow(files)
+}
+
+// removeNow delete files right away whatever the cleanup rate, e.g. when the disk is full
+func (r *RotateWriter) removeNow(files []string) error {
+	defer r.pruneEmptyDirs(files)
+	for _, file := range files {
+		err := r.removeBackup(file)
+		if os.IsNotExist(err) {
+			// removed meanwhile
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		r.stats.deleted.Inc()
+	}
+	return nil
+}
+
+// queueRemoval hand files to removeQueued, files already queued are skipped
+func (r *RotateWriter) queueRemoval(files []string) {
+	q := &r.removals
+	q.mu.Lock()
+	added := false
+	for _, file := range files {
+		if q.queued[file] {
+			continue
+		}
+		if q.queued == nil {
+			q.queued = make(map[string]bool)
+		}
+		q.queued[file] = true
+		q.files = append(q.files, file)
+		added = true
+	}
+	if added && !q.busy {
+		q.busy = true
+		r.addTask()
+	}
+	q.mu.Unlock()
+	if added {
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// removeLoop remove the queued files batch by batch every cleanup interval until the writer is closed
+func (r *RotateWriter) removeLoop() {
+	r.lowerPriority()
+	for {
+		select {
+		case <-r.removals.wake:
+		case <-r.postDone:
+			return
+		}
+		for r.removeQueued() {
+			select {
+			case <-time.After(r.opt.cleanupInterval):
+			case <-r.postDone:
+				return
+			}
+		}
+	}
+}
+
+// removeQueued remove the next batch of queued files, it report whether a batch was removed
+func (r *RotateWriter) removeQueued() bool {
+	q := &r.removals
+	q.mu.Lock()
+	n := r.opt.cleanupBatch
+	if n > len(q.files) {
+		n = len(q.files)
+	}
+	files := q.files[:n:n]
+	q.files = q.files[n:]
+	q.mu.Unlock()
+	if n > 0 {
+		if err := r.removeBatch(files); err != nil {
+			r.setErr(err)
+		}
+		r.pruneEmptyDirs(files)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, file := range files {
+		delete(q.queued, file)
+	}
+	if len(q.files) == 0 && q.busy {
+		q.busy = false
+		r.doneTask()
+	}
+	return n > 0
+}
+
+// removeBatch
+func (r *RotateWriter) removeBatch(files []string) error {
+	workers := r.opt.cleanupWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs error
+	)
+	ch := make(chan string)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.lowerPriority()
+			for file := range ch {
+				err := r.removeBackup(file)
+				if os.IsNotExist(err) {
+					// removed meanwhile, e.g. queued by two retention passes
+					continue
+				}
+				if err != nil {
+					mu.Lock()
+					errs = multierr.Append(errs, err)
+					mu.Unlock()
+					continue
+				}
+				r.stats.deleted.Inc()
+			}
+		}()
+	}
+	for _, file := range files {
+		ch <- file
+	}
+	close(ch)
+	wg.Wait()
+	return errs
+}
+
+// pruneEmptyDirs remove the directories of files, and their parents, once they are empty,
+// it never goes up to the active file directory
+func (r *RotateWriter) pruneEmptyDirs(files []string) {
+	filename, _, _ := r.paths()
+	root := filepath.Clean(filepath.Dir(filename))
+	for _, file := range files {
+		dir := filepath.Clean(filepath.Dir(file))
+		for strings.HasPrefix(dir, root+string(filepath.Separator)) {
+			// fails unless the directory is empty
+			if os.Remove(dir) != nil {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+}
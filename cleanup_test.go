@@ -0,0 +1,94 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_cleanupRate(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "cleanup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+	for i := 1; i <= 6; i++ {
+		name := mockBackupName(filename, time.Now().Add(-time.Duration(i)*time.Hour).Format(defaultTimeFormat))
+		if err := ioutil.WriteFile(name, []byte("test"), defaultFilePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writer, err := NewRotateWriter(filename, WithMaxBackups(1), WithCleanupRate(2, 50*time.Millisecond, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	start := time.Now()
+	writer.removeOverMaxFiles()
+	if elapsed := time.Since(start); elapsed >= 50*time.Millisecond {
+		t.Errorf("rate limited cleanup should not block the caller, took:%v", elapsed)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("cleanup should be rate limited, took:%v", elapsed)
+	}
+	if deleted := writer.Stats().DeletedBackups; deleted != 5 {
+		t.Errorf("deleted backups incorrect, got:%v", deleted)
+	}
+	files, err := writer.listFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("remaining backups incorrect, got:%v", files)
+	}
+}
+
+func TestRotateWriter_pruneEmptyDirs(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "cleanup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	old := []string{
+		filepath.Join(dir, "2023", "01", "app.log"),
+		filepath.Join(dir, "2023", "02", "app.log"),
+	}
+	kept := filepath.Join(dir, "2024", "01", "app.log")
+	for _, name := range append(old, kept) {
+		if err := os.MkdirAll(filepath.Dir(name), defaultDirPerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte("test"), defaultFilePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithBackupPattern(filepath.Join(dir, "*", "*", "app.log")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if err := writer.removeFiles(old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2023")); !os.IsNotExist(err) {
+		t.Error("empty dated directories should be removed")
+	}
+	if _, err := os.Stat(kept); err != nil {
+		t.Errorf("other backups should be kept: %v", err)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("the active file directory should be kept: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package rotate
+
+import (
+	"time"
+)
+
+// Clock tell the writer the current time when naming backups, deciding interval rotations
+// and applying age based retention
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+// Now
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// WithClock use c instead of the system clock, mainly for tests. Interval checks still
+// fire on real timers, they only compare against c.
+func WithClock(c Clock) RotateOption {
+	return func(o *rotateOption) {
+		if c == nil {
+			o.clock = systemClock{}
+			return
+		}
+		o.clock = c
+	}
+}
+
+// now
+func (r *RotateWriter) now() time.Time {
+	return r.opt.clock.Now()
+}
@@ -0,0 +1,13 @@
+package rotate
+
+import "os"
+
+// createFile create or truncate the active file close-on-exec, see cloexecFlag
+func createFile(name string) (*os.File, error) {
+	return os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC|cloexecFlag, 0666)
+}
+
+// appendFile open the existing active file for appending close-on-exec
+func appendFile(name string) (*os.File, error) {
+	return os.OpenFile(name, os.O_APPEND|os.O_WRONLY|cloexecFlag, defaultFilePerm)
+}
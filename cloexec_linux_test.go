@@ -0,0 +1,38 @@
+//go:build linux
+// +build linux
+
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestRotateWriter_CloseOnExec(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "cloexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for i := 0; i < 2; i++ {
+		flags, _, errno := syscall.Syscall(syscall.SYS_FCNTL, writer.fp.Fd(), syscall.F_GETFD, 0)
+		if errno != 0 {
+			t.Fatal(errno)
+		}
+		if flags&syscall.FD_CLOEXEC == 0 {
+			t.Errorf("close-on-exec incorrect, got flags:%x", flags)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
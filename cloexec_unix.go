@@ -0,0 +1,10 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package rotate
+
+import "syscall"
+
+// cloexecFlag close the file on exec from the open call itself, a fork between open and a later
+// CloseOnExec would leak it to the child
+const cloexecFlag = syscall.O_CLOEXEC
@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package rotate
+
+import "syscall"
+
+// cloexecFlag open a handle child processes do not inherit
+const cloexecFlag = syscall.O_CLOEXEC
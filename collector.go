@@ -0,0 +1,134 @@
+package rotate
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WithCollectorMode tune the writer for tailers like Fluent Bit or Vector: the active file is
+// fsynced before rotation, link always point to the active file if not empty, and backups are
+// neither compressed nor removed until their last write is grace old, so a collector still
+// reading a rotated file does not lose its tail nor see its inode reused by a new file
+func WithCollectorMode(link string, grace time.Duration) RotateOption {
+	return func(o *rotateOption) {
+		o.syncBeforeRotate = true
+		o.symlink = link
+		o.deleteGrace = grace
+	}
+}
+
+// WithSyncBeforeRotate fsync the active file before it is renamed to a backup
+func WithSyncBeforeRotate(sync bool) RotateOption {
+	return func(o *rotateOption) {
+		o.syncBeforeRotate = sync
+	}
+}
+
+// WithSymlink keep link pointing to the active file, it is replaced atomically
+func WithSymlink(link string) RotateOption {
+	return func(o *rotateOption) {
+		o.symlink = link
+	}
+}
+
+// WithDeleteGrace delay the compression and the retention removal of a backup until its
+// modification time is grace old, backups too recent are removed by a later cleanup.
+// Directory quota and disk full pruning do not wait.
+func WithDeleteGrace(grace time.Duration) RotateOption {
+	return func(o *rotateOption) {
+		o.deleteGrace = grace
+	}
+}
+
+// linkActive point the symlink to the active file
+func (r *RotateWriter) linkActive() error {
+	if len(r.opt.symlink) == 0 {
+		return nil
+	}
+	target, err := filepath.Abs(r.filename)
+	if err != nil {
+		return err
+	}
+	if current, err := os.Readlink(r.opt.symlink); err == nil && current == target {
+		return nil
+	}
+	tmp := r.opt.symlink + ".tmp"
+	if err = os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err = os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.opt.symlink)
+}
+
+// graceLeft return how long until the backup is grace old
+func (r *RotateWriter) graceLeft(name string) time.Duration {
+	if r.opt.deleteGrace <= 0 {
+		return 0
+	}
+	ts, ok := modTime(name)
+	if !ok {
+		return 0
+	}
+	return r.opt.deleteGrace - time.Since(ts)
+}
+
+// parkGrace keep b aside until it is grace old instead of blocking the worker, it is counted
+// for Wait until processed, only called by afterRotate
+func (r *RotateWriter) parkGrace(b backup) bool {
+	left := r.graceLeft(b.name)
+	if left <= 0 {
+		return false
+	}
+	r.addTask()
+	r.graced = append(r.graced, b)
+	if due := time.Now().Add(left); len(r.graced) == 1 || due.Before(r.graceDue) {
+		r.graceDue = due
+		resetTimer(r.graceTimer, left)
+	}
+	return true
+}
+
+// releaseGraced process the parked backups now grace old and arm the timer for the next one,
+// only called by afterRotate
+func (r *RotateWriter) releaseGraced() {
+	graced := r.graced
+	r.graced = nil
+	var ready []backup
+	for _, b := range graced {
+		left := r.graceLeft(b.name)
+		if left > 0 {
+			if due := time.Now().Add(left); len(r.graced) == 0 || due.Before(r.graceDue) {
+				r.graceDue = due
+			}
+			r.graced = append(r.graced, b)
+			continue
+		}
+		ready = append(ready, b)
+	}
+	if len(r.graced) > 0 {
+		resetTimer(r.graceTimer, time.Until(r.graceDue))
+	}
+	for _, b := range ready {
+		r.process(b)
+		r.doneTask()
+	}
+}
+
+// excludeRecent drop the files modified within the delete grace, modification times come
+// from the filesystem so the grace is measured against the wall clock
+func (r *RotateWriter) excludeRecent(files []string) []string {
+	if r.opt.deleteGrace <= 0 {
+		return files
+	}
+	cutoff := time.Now().Add(-r.opt.deleteGrace)
+	old := make([]string, 0, len(files))
+	for _, file := range files {
+		if ts, ok := modTime(file); !ok || ts.Before(cutoff) {
+			old = append(old, file)
+		}
+	}
+	return old
+}
@@ -0,0 +1,82 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_WithCollectorMode(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "collector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename, link := filepath.Join(dir, "app.log"), filepath.Join(dir, "current.log")
+	grace := 300 * time.Millisecond
+	writer, err := NewRotateWriter(filename, WithGzip(true), WithCollectorMode(link, grace),
+		WithClock(&stepClock{now: time.Now()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if target, err := os.Readlink(link); err != nil || target != filename {
+		t.Errorf("symlink incorrect, got:%s %v", target, err)
+	}
+
+	backupName := writer.backupName
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(backupName); err != nil {
+		t.Errorf("backup should stay plain during the grace, got:%v", err)
+	}
+	start := time.Now()
+	if err := writer.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < grace/2 {
+		t.Errorf("compression should wait for the grace")
+	}
+	if _, err := os.Stat(backupName + ".gz"); err != nil {
+		t.Errorf("backup should be compressed after the grace, got:%v", err)
+	}
+}
+
+func TestRotateWriter_WithDeleteGrace(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "grace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithMaxBackups(1), WithDeleteGrace(time.Hour),
+		WithClock(&stepClock{now: time.Now()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for i := 0; i < 3; i++ {
+		if _, err := writer.Write([]byte("test\n")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if backups, err := writer.Backups(); err != nil {
+		t.Fatal(err)
+	} else if len(backups) != 3 {
+		t.Errorf("recent backups should be kept, got:%+v", backups)
+	}
+}
@@ -0,0 +1,183 @@
+package rotate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"go.uber.org/multierr"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const defaultCompactPeriod = 24 * time.Hour
+
+// WithCompactPeriod set the span of the backups Compact merge together, e.g. time.Hour,
+// default to one day, days follow the time zone of the backup names
+func WithCompactPeriod(d time.Duration) RotateOption {
+	return func(o *rotateOption) {
+		o.compactPeriod = d
+	}
+}
+
+// Compact merge the backups older than olderThan that belong to the same period into one backup
+// named after the oldest of them, gzip compressed with WithGzip. The index, sidecars and quota are updated, retention
+// then applies to the merged backup as a whole and WithUploader uploads it. Backups still waiting for the
+// background worker or for an upload retry are left as they are.
+func (r *RotateWriter) Compact(ctx context.Context, olderThan time.Duration) error {
+	// the background cleanup must not remove members meanwhile
+	release, err := r.holdCleanup(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	backups, err := r.Backups()
+	if err != nil {
+		return err
+	}
+	cutoff := r.now().Add(-olderThan)
+	var group []BackupInfo
+	for _, b := range backups {
+		if b.Timestamp.IsZero() || !b.Timestamp.Before(cutoff) || (r.opt.gzip && !b.Compressed) ||
+			r.isInflight(r.checkpointID(b.Name)) || r.isFailed(b.Name) {
+			continue
+		}
+		if len(group) > 0 && !r.compactPeriodOf(group[0].Timestamp).Equal(r.compactPeriodOf(b.Timestamp)) {
+			if err = r.compactGroup(ctx, group); err != nil {
+				return err
+			}
+			group = group[:0]
+		}
+		group = append(group, b)
+	}
+	if err = r.compactGroup(ctx, group); err != nil {
+		return err
+	}
+	r.enforceQuota()
+	return nil
+}
+
+// compactPeriodOf return the start of the period holding t
+func (r *RotateWriter) compactPeriodOf(t time.Time) time.Time {
+	period := r.opt.compactPeriod
+	if period <= 0 {
+		period = defaultCompactPeriod
+	}
+	if period%defaultCompactPeriod == 0 {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+	return t.Truncate(period)
+}
+
+// compactGroup merge group into the backup of its first member, gzip compressed with WithGzip
+// and plain otherwise so retention still matches it
+func (r *RotateWriter) compactGroup(ctx context.Context, group []BackupInfo) (err error) {
+	if len(group) < 2 {
+		return nil
+	}
+	target := r.checkpointID(group[0].Name)
+	if r.opt.gzip {
+		target += ".gz"
+		release, err := r.reserveCompress(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	tmp := filepath.Join(filepath.Dir(target), "."+filepath.Base(target)+".compact")
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	var w io.WriteCloser = plainWriter{out}
+	if r.opt.gzip {
+		if w, err = gzip.NewWriterLevel(out, r.gzipSettings().level); err != nil {
+			return multierr.Combine(err, out.Close(), os.Remove(tmp))
+		}
+	}
+	stats := fileStats{first: group[0].Timestamp, last: group[len(group)-1].ModTime}
+	for _, b := range group {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		if err = r.copyBackup(w, b.Name, &stats); err != nil {
+			break
+		}
+	}
+	if err = multierr.Combine(err, w.Close(), out.Sync(), out.Close()); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if r.opt.backupAttr != AttrNone {
+		if err = setBackupAttr(target, r.opt.backupAttr, false); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err = os.Rename(tmp, target); err != nil {
+		return err
+	}
+	for _, b := range group {
+		if b.Name != target {
+			err = multierr.Append(err, r.removeBackup(b.Name))
+		}
+	}
+	merged := backup{name: target, start: stats.first, end: stats.last, file: stats,
+		compressed: true, plain: r.checkpointID(target), merged: true}
+	err = multierr.Combine(err, r.chmodBackup(target), r.writeSidecar(merged),
+		r.indexAdd(target, merged.start, merged.end), r.protectBackup(target))
+	if r.opt.uploader != nil {
+		r.mu.Lock()
+		if !r.done.Load() {
+			r.enqueue(merged)
+		}
+		r.mu.Unlock()
+	}
+	return err
+}
+
+// processMerged upload a backup merged by Compact, only called by afterRotate
+func (r *RotateWriter) processMerged(b backup) {
+	// errors are reported by uploadFile, there is no Rotation waiting for them
+	_ = r.uploadFile(context.Background(), b)
+	r.doneInflight(b.plain)
+}
+
+// plainWriter write a merged backup as is, Close leaves the file to compactGroup
+type plainWriter struct {
+	io.Writer
+}
+
+// Close
+func (plainWriter) Close() error {
+	return nil
+}
+
+// copyBackup append the uncompressed content of name to w
+func (r *RotateWriter) copyBackup(w io.Writer, name string, stats *fileStats) (err error) {
+	rc, err := r.openBackup(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Append(err, rc.Close())
+	}()
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := rc.Read(buf)
+		if n > 0 {
+			if _, err = w.Write(buf[:n]); err != nil {
+				return err
+			}
+			stats.bytes += int64(n)
+			stats.lines += int64(bytes.Count(buf[:n], []byte{'\n'}))
+		}
+		if readErr == io.EOF {
+			return nil
+		} else if readErr != nil {
+			return readErr
+		}
+	}
+}
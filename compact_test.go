@@ -0,0 +1,266 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_Compact(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "compact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clock := &stepClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithIndex(""),
+		WithCompactPeriod(time.Hour), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for _, data := range []string{"a\n", "b\n", "c\n"} {
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	before, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Compact(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != 1 || after[0].Name != before[0].Name {
+		t.Fatalf("backups incorrect, got:%v", after)
+	}
+	var got []byte
+	rc, err := writer.openBackup(after[0].Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if got, err = ioutil.ReadAll(rc); err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "a\nb\nc\n" {
+		t.Errorf("content incorrect, got:%q", got)
+	}
+	indexed, err := writer.Query(time.Time{}, clock.now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var live int
+	for _, b := range indexed {
+		if !b.Removed {
+			live++
+		}
+	}
+	if len(indexed) != 3 || live != 1 {
+		t.Errorf("index incorrect, got:%+v", indexed)
+	}
+}
+
+func TestRotateWriter_Compact_plain(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "compact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clock := &stepClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithCompactPeriod(time.Hour), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for _, data := range []string{"a\n", "b\n"} {
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Compact(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// the merged backup stays within reach of retention
+	files, err := writer.listFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || filepath.Ext(files[0]) != ".log" {
+		t.Fatalf("backups incorrect, got:%v", files)
+	}
+	got, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "a\nb\n" {
+		t.Errorf("content incorrect, got:%q", got)
+	}
+}
+
+func TestRotateWriter_Compact_busy(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "compact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	uploader := &blockingUploader{release: make(chan struct{})}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithUploader(uploader),
+		WithCompactPeriod(time.Hour), WithClock(&stepClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for _, data := range []string{"a\n", "b\n", "c\n"} {
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the first backup is uploading, the others are queued
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Compact(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	close(uploader.release)
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	files, err := writer.listFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Errorf("queued backups should not be merged, got:%v", files)
+	}
+	if _, err := writer.Write([]byte("d\n")); err != nil {
+		t.Errorf("queued uploads should succeed, got:%v", err)
+	}
+}
+
+func TestRotateWriter_Compact_failedUpload(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "compact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	uploader := &flakyUploader{fail: true}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithUploader(uploader),
+		WithDeleteAfterUpload(true), WithCompactPeriod(time.Hour),
+		WithClock(&stepClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, data := range []string{"a\n", "b\n"} {
+		_, _ = writer.Write([]byte(data))
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// both wait for an upload retry
+	if err := writer.Compact(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	files, err := writer.listFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("backups waiting for a retry should not be merged, got:%v", files)
+	}
+	uploader.mu.Lock()
+	uploader.fail = false
+	uploader.mu.Unlock()
+	_, _ = writer.Write([]byte("c\n"))
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if files, err = writer.listFiles(); err != nil || len(files) != 0 {
+		t.Errorf("retried uploads should remove the backups, got:%v %v", files, err)
+	}
+}
+
+func TestRotateWriter_Compact_upload(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "compact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	uploader := &mockUploader{}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithUploader(uploader),
+		WithCompactPeriod(time.Hour), WithClock(&stepClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for _, data := range []string{"a\n", "b\n"} {
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Compact(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploader.mu.Lock()
+	defer uploader.mu.Unlock()
+	if len(backups) != 1 || len(uploader.files) != 3 || uploader.files[2] != backups[0].Name {
+		t.Errorf("merged backup should be uploaded, got:%v %v", backups, uploader.files)
+	}
+}
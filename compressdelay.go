@@ -0,0 +1,108 @@
+package rotate
+
+import (
+	"time"
+)
+
+// WithCompressAfter keep backups in plain text until they are older than d, their compression,
+// upload and retention run once they are. The plain backups of a previous run are picked up
+// at start. It requires WithGzip.
+func WithCompressAfter(d time.Duration) RotateOption {
+	return func(o *rotateOption) {
+		o.compressAfter = d
+	}
+}
+
+// WithUncompressedBackups always keep the k most recent backups in plain text, a backup is
+// compressed, uploaded and retained once k newer backups exist. With WithCompressAfter a backup
+// stays plain as long as either rule applies. It requires WithGzip.
+func WithUncompressedBackups(k int) RotateOption {
+	return func(o *rotateOption) {
+		o.plainBackups = k
+	}
+}
+
+// delaying report whether backups wait before compression
+func (r *RotateWriter) delaying() bool {
+	return r.opt.gzip && (r.opt.compressAfter > 0 || r.opt.plainBackups > 0)
+}
+
+// delayBackup keep b in plain text for now, only called by afterRotate
+func (r *RotateWriter) delayBackup(b backup) bool {
+	if !r.delaying() || b.compressed {
+		return false
+	}
+	r.delayed = append(r.delayed, b)
+	r.enforceQuota()
+	return true
+}
+
+// loadDelayed pick up the plain backups left by a previous run, called before afterRotate starts
+func (r *RotateWriter) loadDelayed() {
+	if !r.delaying() {
+		return
+	}
+	backups, err := r.Backups()
+	if err != nil {
+		r.setErr(err)
+		return
+	}
+	for _, b := range backups {
+		if !b.Compressed {
+			r.markInflight(b.Name)
+			r.delayed = append(r.delayed, backup{name: b.Name, start: b.Timestamp, end: b.ModTime})
+		}
+	}
+}
+
+// releaseDelayed process the backups neither recent nor among the newest and return how long
+// until the next one gets old enough, zero if no backup waits on its age, only called by afterRotate
+func (r *RotateWriter) releaseDelayed() time.Duration {
+	now := r.now()
+	newest := len(r.delayed) - r.opt.plainBackups
+	kept := r.delayed[:0]
+	var wait time.Duration
+	for i, b := range r.delayed {
+		age := now.Sub(b.end)
+		if age < r.opt.compressAfter || (r.opt.plainBackups > 0 && i >= newest) {
+			kept = append(kept, b)
+			if i < newest && (wait == 0 || r.opt.compressAfter-age < wait) {
+				wait = r.opt.compressAfter - age
+			}
+			continue
+		}
+		if !r.deferBackup(b) {
+			r.process(b)
+		}
+	}
+	r.delayed = kept
+	return wait
+}
+
+// scheduleDelayed release the backups old enough and arm t for the next one
+func (r *RotateWriter) scheduleDelayed(t *time.Timer) {
+	if wait := r.releaseDelayed(); wait > 0 {
+		resetTimer(t, wait)
+	}
+}
+
+// isDelayed
+func (r *RotateWriter) isDelayed(name string) bool {
+	for _, b := range r.delayed {
+		if b.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// resetTimer
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
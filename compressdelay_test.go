@@ -0,0 +1,95 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_WithCompressAfter(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "compressafter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clock := &manualClock{now: time.Now()}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithCompressAfter(time.Hour),
+		WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	rotate := func() []BackupInfo {
+		// distinct backup names
+		clock.Add(time.Second)
+		if _, err := writer.Write([]byte("test\n")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := writer.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+		backups, err := writer.Backups()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return backups
+	}
+
+	if backups := rotate(); len(backups) != 1 || backups[0].Compressed {
+		t.Fatalf("recent backup should stay plain, got:%+v", backups)
+	}
+	clock.Add(2 * time.Hour)
+	backups := rotate()
+	if len(backups) != 2 || !backups[0].Compressed || backups[1].Compressed {
+		t.Errorf("old backup should be compressed, got:%+v", backups)
+	}
+}
+
+func TestRotateWriter_WithUncompressedBackups(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "plainbackups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithUncompressedBackups(2),
+		WithClock(&stepClock{now: time.Now()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for i := 0; i < 4; i++ {
+		if _, err := writer.Write([]byte("test\n")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 4 {
+		t.Fatalf("backups incorrect, got:%+v", backups)
+	}
+	for i, b := range backups {
+		if want := i < 2; b.Compressed != want {
+			t.Errorf("backup %d compressed incorrect, got:%v", i, b.Compressed)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"go.uber.org/multierr"
+	"io"
+	"os"
+)
+
+const defaultCompressBuffer = 32 * 1024
+
+// CompressPool cap the compressions running at once across the writers sharing it, so a host
+// rotating several large files at the same time compresses them in turn
+type CompressPool struct {
+	slots chan struct{}
+}
+
+// NewCompressPool return a pool running at most n compressions at once, n <= 0 means one
+func NewCompressPool(n int) *CompressPool {
+	if n <= 0 {
+		n = 1
+	}
+	return &CompressPool{slots: make(chan struct{}, n)}
+}
+
+// acquire wait for a free slot or ctx
+func (p *CompressPool) acquire(ctx context.Context) error {
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release
+func (p *CompressPool) release() {
+	<-p.slots
+}
+
+// WithCompressPool run the compressions and compactions of the writer through p, a pool is usually
+// shared by every writer of the process
+func WithCompressPool(p *CompressPool) RotateOption {
+	return func(o *rotateOption) {
+		o.compressPool = p
+	}
+}
+
+// WithCompressLevel set the gzip level, default gzip.DefaultCompression. The window of
+// compress/gzip is fixed, lower levels mostly use less memory: about 1MB per compression
+// for levels 2 to 9, 480KB for gzip.BestSpeed and 320KB for gzip.HuffmanOnly
+func WithCompressLevel(level int) RotateOption {
+	return func(o *rotateOption) {
+		o.compressLevel = &level
+	}
+}
+
+// WithCompressBuffer set the size of the buffer a backup is read through while compressed, default 32KB
+func WithCompressBuffer(size int) RotateOption {
+	return func(o *rotateOption) {
+		o.compressBuffer = size
+	}
+}
+
+// gzipSettings
+type gzipSettings struct {
+	level  int
+	buffer int
+}
+
+var defaultGzip = gzipSettings{level: gzip.DefaultCompression, buffer: defaultCompressBuffer}
+
+// gzipSettings return the settings from WithCompressLevel and WithCompressBuffer
+func (r *RotateWriter) gzipSettings() gzipSettings {
+	s := defaultGzip
+	if r.opt.compressLevel != nil {
+		s.level = *r.opt.compressLevel
+	}
+	if r.opt.compressBuffer > 0 {
+		s.buffer = r.opt.compressBuffer
+	}
+	return s
+}
+
+// memory estimate the bytes held by a compression
+func (s gzipSettings) memory() int64 {
+	size := int64(s.buffer)
+	switch s.level {
+	case gzip.HuffmanOnly:
+		return size + 320<<10
+	case gzip.BestSpeed:
+		return size + 480<<10
+	default:
+		return size + 1<<20
+	}
+}
+
+// copy compress in to out, it stop with ctx.Err() once ctx is done
+func (s gzipSettings) copy(ctx context.Context, out io.Writer, in io.Reader) error {
+	w, err := gzip.NewWriterLevel(out, s.level)
+	if err != nil {
+		return err
+	}
+	// the wrapper also hides io.WriterTo, *os.File implements it and CopyBuffer would then
+	// ignore the buffer
+	_, err = io.CopyBuffer(w, ctxReader{ctx: ctx, r: in}, make([]byte, s.buffer))
+	return multierr.Append(err, w.Close())
+}
+
+// file compress filename to filename.gz and remove filename
+func (s gzipSettings) file(ctx context.Context, filename string) error {
+	if err := s.keep(ctx, filename); err != nil {
+		return err
+	}
+	return os.Remove(filename)
+}
+
+// keep compress filename to filename.gz and keep filename, a cancelled compression leaves nothing
+func (s gzipSettings) keep(ctx context.Context, filename string) (err error) {
+	in, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Append(err, in.Close())
+	}()
+
+	// a crash mid-compression leaves a .gz.tmp, never a truncated .gz
+	target := fmt.Sprintf("%s.gz", filename)
+	tmp := target + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	err = s.copy(ctx, out, in)
+	if err = multierr.Combine(err, out.Sync(), out.Close()); err != nil {
+		return multierr.Append(err, os.Remove(tmp))
+	}
+	return os.Rename(tmp, target)
+}
+
+// ctxReader fail reads once ctx is done so a compression can be cancelled
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read
+func (c ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// reserveCompress wait for a slot of the compress pool and account the memory of a compression
+// in Stats, the returned func release both
+func (r *RotateWriter) reserveCompress(ctx context.Context) (func(), error) {
+	if p := r.opt.compressPool; p != nil {
+		if err := p.acquire(ctx); err != nil {
+			return nil, err
+		}
+	}
+	mem := r.gzipSettings().memory()
+	r.stats.compressMemory.Add(mem)
+	return func() {
+		r.stats.compressMemory.Sub(mem)
+		if p := r.opt.compressPool; p != nil {
+			p.release()
+		}
+	}, nil
+}
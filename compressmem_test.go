@@ -0,0 +1,103 @@
+package rotate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_WithCompressPool(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "compresspool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pool := NewCompressPool(1)
+	// another writer holds the only slot
+	if err := pool.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithCompressPool(pool),
+		WithCompressLevel(gzip.BestSpeed), WithCompressBuffer(4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := writer.Wait(ctx); err == nil {
+		t.Fatal("compression should wait for the pool")
+	}
+	if mem := writer.Stats().CompressMemory; mem != 0 {
+		t.Errorf("memory of a waiting compression incorrect, got:%v", mem)
+	}
+
+	pool.release()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 || !backups[0].Compressed || !gzipComplete(backups[0].Name) {
+		t.Errorf("backups incorrect, got:%+v", backups)
+	}
+	if mem := writer.Stats().CompressMemory; mem != 0 {
+		t.Errorf("memory after compression incorrect, got:%v", mem)
+	}
+}
+
+func TestGzipSettings_memory(t *testing.T) {
+	huffman := gzipSettings{level: gzip.HuffmanOnly, buffer: defaultCompressBuffer}.memory()
+	best := gzipSettings{level: gzip.BestCompression, buffer: defaultCompressBuffer}.memory()
+	if huffman >= best {
+		t.Errorf("memory estimate incorrect, got:%v >= %v", huffman, best)
+	}
+	if small := (gzipSettings{level: gzip.HuffmanOnly, buffer: 1024}).memory(); small >= huffman {
+		t.Errorf("memory estimate incorrect, got:%v >= %v", small, huffman)
+	}
+}
+
+// readSizes record the size of every Read, bytes.Reader implements io.WriterTo like *os.File
+type readSizes struct {
+	*bytes.Reader
+	sizes []int
+}
+
+func (r *readSizes) Read(p []byte) (int, error) {
+	r.sizes = append(r.sizes, len(p))
+	return r.Reader.Read(p)
+}
+
+func TestGzipSettings_copy(t *testing.T) {
+	in := &readSizes{Reader: bytes.NewReader(make([]byte, 10000))}
+	var out bytes.Buffer
+	if err := (gzipSettings{level: gzip.BestSpeed, buffer: 4096}).copy(context.Background(), &out, in); err != nil {
+		t.Fatal(err)
+	}
+	if len(in.sizes) == 0 {
+		t.Fatal("the source should be read through the buffer")
+	}
+	for _, size := range in.sizes {
+		if size != 4096 {
+			t.Errorf("read size incorrect, got:%v", in.sizes)
+			break
+		}
+	}
+}
@@ -11,4 +11,5 @@ const (
 	defaultMaxBackups = 30
 	defaultDelimiter  = "-"
 	defaultTimeFormat = time.RFC3339 //"2006-01-02T15:04:05Z07:00"
+	rotatingSuffix    = ".rotating"  // the active file while a rotation is in progress
 )
@@ -0,0 +1,93 @@
+package rotate
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+var (
+	ErrUnknownCommand     = errors.New("error: unknown command")
+	ErrControlSocketInUse = errors.New("error: control socket is in use")
+)
+
+// WithControlSocket listen on the unix socket path for one command per line: "rotate",
+// "flush" to fsync the active file, or "stats" answered with a JSON line. The other commands
+// are answered "ok" or "error: " and the reason. A stale socket left at path is replaced, one
+// still served by another process fails with ErrControlSocketInUse.
+func WithControlSocket(path string) RotateOption {
+	return func(o *rotateOption) {
+		o.controlSocket = path
+	}
+}
+
+// listenControl replace the socket at the path only if nothing listens on it anymore
+func (r *RotateWriter) listenControl() (net.Listener, error) {
+	if fi, err := os.Lstat(r.opt.controlSocket); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		conn, err := net.Dial("unix", r.opt.controlSocket)
+		if err == nil {
+			_ = conn.Close()
+			return nil, ErrControlSocketInUse
+		}
+		if !connRefused(err) {
+			return nil, err
+		}
+		if err = os.Remove(r.opt.controlSocket); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", r.opt.controlSocket)
+}
+
+// serveControl accept connections until Close closes the listener
+func (r *RotateWriter) serveControl(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go r.handleControl(conn)
+	}
+}
+
+// handleControl
+func (r *RotateWriter) handleControl(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		reply, err := r.control(strings.TrimSpace(scanner.Text()))
+		if err != nil {
+			reply = "error: " + strings.TrimPrefix(err.Error(), "error: ")
+		}
+		if _, err = fmt.Fprintln(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// control run one command
+func (r *RotateWriter) control(cmd string) (string, error) {
+	switch cmd {
+	case "rotate":
+		_, err := r.Rotate()
+		return "ok", err
+	case "flush":
+		return "ok", r.Sync()
+	case "stats":
+		stats := struct {
+			Stats
+			LastError string `json:",omitempty"`
+		}{Stats: r.Stats()}
+		if stats.Stats.LastError != nil {
+			stats.LastError = stats.Stats.LastError.Error()
+		}
+		data, err := json.Marshal(stats)
+		return string(data), err
+	default:
+		return "", ErrUnknownCommand
+	}
+}
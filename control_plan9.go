@@ -0,0 +1,6 @@
+package rotate
+
+// connRefused unix sockets are not available on plan9
+func connRefused(error) bool {
+	return false
+}
@@ -0,0 +1,14 @@
+//go:build !plan9 && !windows
+// +build !plan9,!windows
+
+package rotate
+
+import (
+	"errors"
+	"syscall"
+)
+
+// connRefused report whether nothing listens on the socket anymore
+func connRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
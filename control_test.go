@@ -0,0 +1,122 @@
+//go:build !plan9
+// +build !plan9
+
+package rotate
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotateWriter_WithControlSocket(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "control")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sock := filepath.Join(dir, "rotate.sock")
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithControlSocket(sock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	send := func(cmd string) string {
+		if _, err := conn.Write([]byte(cmd + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		return line
+	}
+
+	for _, cmd := range []string{"flush", "rotate"} {
+		if reply := send(cmd); reply != "ok\n" {
+			t.Errorf("%s incorrect, got:%q", cmd, reply)
+		}
+	}
+	var stats Stats
+	if err := json.Unmarshal([]byte(send("stats")), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Writes != 1 || stats.Rotations != 1 {
+		t.Errorf("stats incorrect, got:%+v", stats)
+	}
+	if reply := send("reload"); reply != "error: unknown command\n" {
+		t.Errorf("unknown command incorrect, got:%q", reply)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// a new writer replace the socket left behind
+	writer, err = NewRotateWriter(filepath.Join(dir, "app.log"), WithControlSocket(sock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+}
+
+func TestRotateWriter_WithControlSocket_inUse(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "control")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sock := filepath.Join(dir, "rotate.sock")
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithControlSocket(sock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := NewRotateWriter(filepath.Join(dir, "other.log"), WithControlSocket(sock)); err != ErrControlSocketInUse {
+		t.Fatalf("a served socket should not be replaced, got:%v", err)
+	}
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		t.Fatalf("the first writer should still be reachable, got:%v", err)
+	}
+	_ = conn.Close()
+}
+
+func TestRotateWriter_WithControlSocket_stale(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "control")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a socket left behind by a crashed process
+	sock := filepath.Join(dir, "rotate.sock")
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: sock, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetUnlinkOnClose(false)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithControlSocket(sock))
+	if err != nil {
+		t.Fatalf("a stale socket should be replaced, got:%v", err)
+	}
+	defer writer.Close()
+}
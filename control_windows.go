@@ -0,0 +1,14 @@
+package rotate
+
+import (
+	"errors"
+	"syscall"
+)
+
+// wsaeconnrefused is WSAECONNREFUSED, not defined by package syscall
+const wsaeconnrefused = syscall.Errno(10061)
+
+// connRefused report whether nothing listens on the socket anymore
+func connRefused(err error) bool {
+	return errors.Is(err, wsaeconnrefused) || errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,57 @@
+package rotate
+
+import (
+	"bytes"
+	"fmt"
+	"go.uber.org/multierr"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+)
+
+const (
+	// crashTimeFormat is the timestamp layout of crash files, without characters reserved on windows
+	crashTimeFormat = "2006-01-02T15-04-05.000"
+	crashInfix      = ".crash-"
+)
+
+// DumpOnPanic write a crash file when the goroutine deferring it panics, then panic again with
+// the same value, e.g. defer writer.DumpOnPanic() at the top of main and long-lived goroutines
+func (r *RotateWriter) DumpOnPanic() {
+	if v := recover(); v != nil {
+		_, _ = r.CrashDump(fmt.Sprintf("panic: %v", v), debug.Stack())
+		panic(v)
+	}
+}
+
+// CrashDump write reason, stack and the lines of WithMemoryTail to a new crash file next to the
+// active file, named after it with .crash- and the time, e.g. app.log.crash-2006-01-02T15-04-05.000.
+// The file is written and synced directly, the lines still buffered by the writer are included.
+// It return the name of the crash file.
+func (r *RotateWriter) CrashDump(reason string, stack []byte) (string, error) {
+	filename, _, _ := r.paths()
+	name := filename + crashInfix + r.now().Format(crashTimeFormat)
+
+	var buf bytes.Buffer
+	buf.WriteString(reason)
+	buf.WriteString("\n\n")
+	buf.Write(stack)
+	if tail := r.Tail(0); len(tail) > 0 {
+		buf.WriteString("\n--- last lines ---\n")
+		for _, line := range tail {
+			buf.Write(line)
+			buf.WriteByte('\n')
+		}
+	}
+
+	fp, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL|cloexecFlag, defaultFilePerm)
+	if err != nil {
+		return "", err
+	}
+	_, err = fp.Write(buf.Bytes())
+	if err = multierr.Combine(err, fp.Sync(), fp.Close()); err != nil {
+		return "", err
+	}
+	// durable whatever WithDirSync
+	return name, syncDir(filepath.Dir(name))
+}
@@ -0,0 +1,55 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotateWriter_DumpOnPanic(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "crash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithMemoryTail(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := writer.Write([]byte("before the crash\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	var recovered interface{}
+	func() {
+		defer func() {
+			recovered = recover()
+		}()
+		defer writer.DumpOnPanic()
+		panic("boom")
+	}()
+	if recovered != "boom" {
+		t.Errorf("panic should be raised again, got:%v", recovered)
+	}
+
+	dumps, err := filepath.Glob(filepath.Join(dir, "app.log.crash-*"))
+	if err != nil || len(dumps) != 1 {
+		t.Fatalf("crash files incorrect, got:%v %v", dumps, err)
+	}
+	data, err := ioutil.ReadFile(dumps[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"panic: boom\n", "TestRotateWriter_DumpOnPanic", "--- last lines ---\nbefore the crash\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("crash file should contain %q, got:%s", want, data)
+		}
+	}
+	if backups, err := writer.Backups(); err != nil || len(backups) != 0 {
+		t.Errorf("crash file should not be a backup, got:%v %v", backups, err)
+	}
+}
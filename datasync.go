@@ -0,0 +1,39 @@
+package rotate
+
+import (
+	"os"
+)
+
+// WithDataSync flush with fdatasync instead of fsync so durability flushes skip metadata
+// like the modification time, it fall back to fsync where fdatasync is not available
+func WithDataSync(enable bool) RotateOption {
+	return func(o *rotateOption) {
+		o.dataSync = enable
+	}
+}
+
+// syncFile flush the active file, the lock must be held
+func (r *RotateWriter) syncFile() error {
+	if err := r.flushBuffer(); err != nil {
+		return err
+	}
+	if err := r.flush(r.fp); err != nil {
+		return err
+	}
+	r.setSynced(r.writeSeq)
+	return nil
+}
+
+// flush fp honoring WithDataSync
+func (r *RotateWriter) flush(fp *os.File) error {
+	var err error
+	if r.opt.dataSync {
+		err = fdatasync(fp)
+	} else {
+		err = fp.Sync()
+	}
+	if err == nil {
+		r.stats.syncs.Inc()
+	}
+	return err
+}
@@ -0,0 +1,22 @@
+//go:build linux
+// +build linux
+
+package rotate
+
+import (
+	"os"
+	"syscall"
+)
+
+// fdatasync
+func fdatasync(fp *os.File) error {
+	for {
+		err := syscall.Fdatasync(int(fp.Fd()))
+		if err != syscall.EINTR {
+			if err != nil {
+				return &os.PathError{Op: "fdatasync", Path: fp.Name(), Err: err}
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package rotate
+
+import (
+	"os"
+)
+
+// fdatasync
+func fdatasync(fp *os.File) error {
+	return fp.Sync()
+}
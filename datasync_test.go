@@ -0,0 +1,36 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotateWriter_WithDataSync(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "datasync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithDataSync(true), WithSyncBeforeRotate(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Sync(); err != nil {
+		t.Errorf("sync incorrect, got:%v", err)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Errorf("rotate incorrect, got:%v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Errorf("close incorrect, got:%v", err)
+	}
+	if err := writer.Sync(); err != ErrLogFileClosed {
+		t.Errorf("sync after close incorrect, got:%v", err)
+	}
+}
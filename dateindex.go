@@ -0,0 +1,88 @@
+package rotate
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WithDateIndex name backups prefix-date.N.ext, e.g. app-2024-05-01.3.log, where N count the
+// rotations within the same date starting at 1. Use it with a date only WithTimeFormat.
+func WithDateIndex(enable bool) RotateOption {
+	return func(o *rotateOption) {
+		o.dateIndex = enable
+	}
+}
+
+// indexedName return base.N.ext with N one more than the backups already named after base
+func (r *RotateWriter) indexedName(base, ext string) string {
+	next := 1
+	matches, _ := filepath.Glob(globEscape(base+".") + "*")
+	for _, match := range matches {
+		if n, ok := dateIndexOf(strings.TrimPrefix(match, base), ext); ok && n >= next {
+			next = n + 1
+		}
+	}
+	return base + "." + strconv.Itoa(next) + ext
+}
+
+// dateIndexOf parse the index of the rest of a backup name after the date, ".3.log" or ".3.log.gz"
+func dateIndexOf(rest, ext string) (int, bool) {
+	if !strings.HasPrefix(rest, ".") {
+		return 0, false
+	}
+	rest = rest[1:]
+	end := 0
+	for end < len(rest) && rest[end] >= '0' && rest[end] <= '9' {
+		end++
+	}
+	if end == 0 || !strings.HasPrefix(rest[end:], ext) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(rest[:end])
+	return n, err == nil
+}
+
+// trimDateIndex drop the .N suffix of a date
+func trimDateIndex(date string) (string, int) {
+	i := strings.LastIndexByte(date, '.')
+	if i < 0 {
+		return date, 0
+	}
+	n, ok := dateIndexOf(date[i:], "")
+	if !ok || i+1+len(strconv.Itoa(n)) != len(date) {
+		return date, 0
+	}
+	return date[:i], n
+}
+
+// backupIndex return the index of a backup name, 0 without WithDateIndex
+func (r *RotateWriter) backupIndex(name string) int {
+	if !r.opt.dateIndex {
+		return 0
+	}
+	_, prefix, ext := r.paths()
+	date := strings.TrimPrefix(r.trimBackupExt(name, ext), prefix+r.opt.delimiter)
+	_, n := trimDateIndex(date)
+	return n
+}
+
+// sortBackupNames order backups by name, rotations of the same date by index
+func (r *RotateWriter) sortBackupNames(files []string) {
+	if !r.opt.dateIndex {
+		sort.Strings(files)
+		return
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		ti, _ := r.parseBackupTime(files[i])
+		tj, _ := r.parseBackupTime(files[j])
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		if ni, nj := r.backupIndex(files[i]), r.backupIndex(files[j]); ni != nj {
+			return ni < nj
+		}
+		return files[i] < files[j]
+	})
+}
@@ -0,0 +1,61 @@
+package rotate
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_WithDateIndex(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "dateindex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clock := &manualClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithTimeFormat("2006-01-02"), WithLocalTime(false),
+		WithDateIndex(true), WithMaxBackups(10), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for i := 0; i < 11; i++ {
+		if _, err := writer.Write([]byte("test\n")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 10 {
+		t.Fatalf("backups incorrect, got:%+v", backups)
+	}
+	// the first one is removed by retention, .10 and .11 sort after .9
+	for i, b := range backups {
+		want := filepath.Join(dir, fmt.Sprintf("app-2024-05-01.%d.log", i+2))
+		if b.Name != want || !b.Timestamp.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("backup %d incorrect, got:%+v want:%s", i, b, want)
+		}
+	}
+
+	clock.Add(24 * time.Hour)
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "app-2024-05-02.1.log"); writer.backupName != want {
+		t.Errorf("new date should restart the index, got:%s", writer.backupName)
+	}
+}
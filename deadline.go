@@ -0,0 +1,104 @@
+package rotate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const cleanupPoll = 10 * time.Millisecond
+
+var ErrTaskTimeout = errors.New("error: background task timed out")
+
+// TaskTimeouts bound the background operations, zero means no limit
+type TaskTimeouts struct {
+	Compress time.Duration // compression of one backup
+	Cleanup  time.Duration // one retention and quota pass
+	Upload   time.Duration // upload of one backup, also set as the context deadline of the Uploader
+}
+
+// WithTaskTimeouts stop waiting for a background operation after its timeout so a hung
+// filesystem or remote storage does not wedge the processing of later backups. The timeout is
+// reported like any background error, a timed out compression is cancelled and its partial
+// output removed, the backup stays plain. A timed out upload is retried with
+// WithDeleteAfterUpload, and a cleanup pass is skipped while a timed out one is still running.
+func WithTaskTimeouts(t TaskTimeouts) RotateOption {
+	return func(o *rotateOption) {
+		o.timeouts = t
+	}
+}
+
+// runTask run fn for at most d, on timeout fn keep running in the background
+func (r *RotateWriter) runTask(d time.Duration, op, name string, fn func() error) error {
+	if d <= 0 {
+		return fn()
+	}
+	done := make(chan error, 1)
+	go func() {
+		r.lowerPriority()
+		done <- fn()
+	}()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return taskTimeout(op, name)
+	}
+}
+
+// withTaskTimeout return ctx bounded by d, zero means no limit
+func withTaskTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// taskTimeout
+func taskTimeout(op, name string) error {
+	if len(name) == 0 {
+		return fmt.Errorf("%w: %s", ErrTaskTimeout, op)
+	}
+	return fmt.Errorf("%w: %s %s", ErrTaskTimeout, op, name)
+}
+
+// holdCleanup wait for the running cleanup pass and keep new ones from starting until release is called
+func (r *RotateWriter) holdCleanup(ctx context.Context) (release func(), err error) {
+	for !r.cleaning.CAS(false, true) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(cleanupPoll):
+		}
+	}
+	return func() {
+		r.cleaning.Store(false)
+	}, nil
+}
+
+// cleanup run one retention and quota pass, unless the previous one is still running
+func (r *RotateWriter) cleanup() {
+	if !r.cleaning.CAS(false, true) {
+		return
+	}
+	_, span := r.startSpan(context.Background(), "cleanup")
+	span.SetAttribute("rotate.file", r.filename)
+	start, deleted := time.Now(), r.stats.deleted.Load()
+	err := r.runTask(r.opt.timeouts.Cleanup, "cleanup", "", func() error {
+		defer r.cleaning.Store(false)
+		r.removeOutdatedFiles()
+		r.removeOverMaxFiles()
+		r.enforceQuota()
+		return nil
+	})
+	deleted = r.stats.deleted.Load() - deleted
+	span.SetAttribute("rotate.deleted", deleted)
+	span.End(err)
+	r.emit(CleanupCompleted{Deleted: deleted, Duration: time.Since(start), Err: err})
+	if err != nil {
+		r.setErr(err)
+	}
+}
@@ -0,0 +1,91 @@
+package rotate
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_WithTaskTimeouts(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "deadline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	uploader := &blockingUploader{release: make(chan struct{})}
+	defer close(uploader.release)
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithUploader(uploader),
+		WithTaskTimeouts(TaskTimeouts{Upload: 50 * time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatalf("a hung upload should not block the worker, got:%v", err)
+	}
+	if _, err := writer.Write([]byte("test\n")); !errors.Is(err, ErrTaskTimeout) {
+		t.Errorf("timeout should be reported, got:%v", err)
+	}
+}
+
+func TestRunTask(t *testing.T) {
+	r := &RotateWriter{opt: &rotateOption{}}
+	want := errors.New("failed")
+	if err := r.runTask(time.Second, "test", "", func() error { return want }); err != want {
+		t.Errorf("task error incorrect, got:%v", err)
+	}
+	release := make(chan struct{})
+	defer close(release)
+	err := r.runTask(10*time.Millisecond, "compress", "app.log", func() error {
+		<-release
+		return nil
+	})
+	if !errors.Is(err, ErrTaskTimeout) || err.Error() != "error: background task timed out: compress app.log" {
+		t.Errorf("timeout incorrect, got:%v", err)
+	}
+}
+
+func TestRotateWriter_compressTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "deadline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true),
+		WithTaskTimeouts(TaskTimeouts{Compress: time.Nanosecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backup := filepath.Join(dir, "app-"+time.Now().Format(defaultTimeFormat)+".log")
+	if err := ioutil.WriteFile(backup, []byte("test\n"), defaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	name, err := writer.compressBackup(context.Background(), backup)
+	if !errors.Is(err, ErrTaskTimeout) || name != backup {
+		t.Fatalf("timeout incorrect, got:%v %v", name, err)
+	}
+	// cancelled rather than left running, nothing is written once compressBackup returns
+	for _, leftover := range []string{backup + ".gz", backup + ".gz.tmp"} {
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Errorf("%s incorrect, got:%v", leftover, err)
+		}
+	}
+	if _, err := os.Stat(backup); err != nil {
+		t.Errorf("backup should stay plain, got:%v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package rotate
+
+import (
+	"path/filepath"
+)
+
+// WithDirSync fsync the directory after the active file is created or renamed so the rotation
+// itself survives a power loss, it is on by default with WithSyncEveryWrite or WithSyncBeforeRotate
+func WithDirSync(enable bool) RotateOption {
+	return func(o *rotateOption) {
+		o.dirSync = &enable
+	}
+}
+
+// dirSyncEnabled
+func (r *RotateWriter) dirSyncEnabled() bool {
+	if r.opt.dirSync != nil {
+		return *r.opt.dirSync
+	}
+	return r.opt.syncEveryWrite || r.opt.syncBeforeRotate
+}
+
+// syncDirs fsync the directories holding names once each
+func (r *RotateWriter) syncDirs(names ...string) error {
+	if !r.dirSyncEnabled() {
+		return nil
+	}
+	synced := make(map[string]bool, len(names))
+	for _, name := range names {
+		dir := filepath.Dir(name)
+		if synced[dir] {
+			continue
+		}
+		synced[dir] = true
+		if err := syncDir(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package rotate
+
+import (
+	"go.uber.org/multierr"
+	"os"
+)
+
+// syncDir fsync dir so the entries created, renamed or removed in it are durable
+func syncDir(dir string) error {
+	fp, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	return multierr.Append(fp.Sync(), fp.Close())
+}
@@ -0,0 +1,48 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotateWriter_WithDirSync(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "dirsync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, c := range []struct {
+		name    string
+		options []RotateOption
+		want    bool
+	}{
+		{"default", nil, false},
+		{"sync every write", []RotateOption{WithSyncEveryWrite(true)}, true},
+		{"sync before rotate", []RotateOption{WithSyncBeforeRotate(true)}, true},
+		{"disabled", []RotateOption{WithSyncEveryWrite(true), WithDirSync(false)}, false},
+		{"enabled", []RotateOption{WithDirSync(true)}, true},
+	} {
+		writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), c.options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := writer.dirSyncEnabled(); got != c.want {
+			t.Errorf("%s incorrect, got:%v", c.name, got)
+		}
+		if _, err := writer.Write([]byte("test\n")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Errorf("%s close incorrect, got:%v", c.name, err)
+		}
+		if err := writer.syncDirs(filepath.Join(dir, "missing", "app.log")); (err != nil) != c.want {
+			t.Errorf("%s missing directory incorrect, got:%v", c.name, err)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package rotate
+
+// syncDir is a no-op, directories can not be flushed on windows and NTFS journals renames
+func syncDir(string) error {
+	return nil
+}
@@ -0,0 +1,108 @@
+package rotate
+
+import (
+	"errors"
+	"path/filepath"
+)
+
+// freeSpaceCheckBytes is how much can be written between two free space checks
+const freeSpaceCheckBytes = 1 << 20
+
+// errDiskSpaceUnknown is returned by diskSpace on platforms without statfs
+var errDiskSpaceUnknown = errors.New("error: free space unknown")
+
+// DiskFullPolicy decide what happens when the free space drops below WithMinFreeSpace
+type DiskFullPolicy int
+
+const (
+	// DiskFullFail reject the write with ErrDiskFull
+	DiskFullFail DiskFullPolicy = iota
+	// DiskFullPrune remove the oldest backups until enough space is free, then reject the
+	// write with ErrDiskFull if it is still not enough
+	DiskFullPrune
+)
+
+var ErrDiskFull = errors.New("error: not enough free disk space")
+
+// WithMinFreeSpace keep at least bytes free on the log filesystem, checked before rotation
+// and every megabyte written
+func WithMinFreeSpace(bytes int64) RotateOption {
+	return func(o *rotateOption) {
+		o.minFreeBytes = bytes
+	}
+}
+
+// WithMinFreePercent keep at least percent of the log filesystem free
+func WithMinFreePercent(percent float64) RotateOption {
+	return func(o *rotateOption) {
+		o.minFreePercent = percent
+	}
+}
+
+// WithDiskFullPolicy set what happens when free space is low, default DiskFullFail
+func WithDiskFullPolicy(p DiskFullPolicy) RotateOption {
+	return func(o *rotateOption) {
+		o.diskFull = p
+	}
+}
+
+// checkFreeSpace must be called while holding the lock
+func (r *RotateWriter) checkFreeSpace(size int64, rotating bool) error {
+	if r.opt.minFreeBytes <= 0 && r.opt.minFreePercent <= 0 {
+		return nil
+	}
+	r.sinceSpaceCheck += size
+	if !rotating && r.sinceSpaceCheck < freeSpaceCheckBytes {
+		return nil
+	}
+
+	// the counter is only reset by a passing check, so a failed one is retried on the next write
+	ok, err := r.enoughFreeSpace(size)
+	if err != nil {
+		return err
+	}
+	if ok {
+		r.sinceSpaceCheck = 0
+		return nil
+	}
+	if r.opt.diskFull == DiskFullPrune {
+		backups, err := r.Backups()
+		if err != nil {
+			return err
+		}
+		for _, b := range backups {
+			// the space is needed now, whatever the cleanup rate
+			if err = r.removeNow([]string{b.Name}); err != nil {
+				return err
+			}
+			if ok, err = r.enoughFreeSpace(size); err != nil {
+				return err
+			}
+			if ok {
+				r.sinceSpaceCheck = 0
+				return nil
+			}
+		}
+	}
+	return ErrDiskFull
+}
+
+// enoughFreeSpace report whether size more bytes leave the minimum free space
+func (r *RotateWriter) enoughFreeSpace(size int64) (bool, error) {
+	filename, _, _ := r.paths()
+	free, total, err := diskSpace(filepath.Dir(filename))
+	if err == errDiskSpaceUnknown {
+		// nothing to check against on this platform
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	free -= size
+	if r.opt.minFreeBytes > 0 && free < r.opt.minFreeBytes {
+		return false, nil
+	}
+	if r.opt.minFreePercent > 0 && total > 0 && float64(free)/float64(total)*100 < r.opt.minFreePercent {
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,49 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_WithMinFreeSpace(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "diskfull")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+	backup := mockBackupName(filename, time.Now().Add(-time.Hour).Format(defaultTimeFormat))
+	if err := ioutil.WriteFile(backup, []byte("test"), defaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(filename, WithMinFreeSpace(1<<62), WithDiskFullPolicy(DiskFullPrune))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := writer.Write([]byte("small\n")); err != nil {
+		t.Fatalf("small writes should not check free space, got:%v", err)
+	}
+	if _, err := writer.Write(make([]byte, freeSpaceCheckBytes)); err != ErrDiskFull {
+		t.Errorf("large write incorrect, got:%v", err)
+	}
+	if _, err := os.Stat(backup); !os.IsNotExist(err) {
+		t.Error("prune policy should remove the backups")
+	}
+	if _, err := writer.Write([]byte("small\n")); err != ErrDiskFull {
+		t.Errorf("a failed check should be retried on the next write, got:%v", err)
+	}
+
+	writer, err = NewRotateWriter(filename, WithMinFreePercent(0.001))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := writer.Write(make([]byte, freeSpaceCheckBytes)); err != nil {
+		t.Errorf("write with enough free space incorrect, got:%v", err)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux
+// +build !darwin,!dragonfly,!freebsd,!linux
+
+package rotate
+
+// diskSpace is unknown without statfs, WithMinFreeSpace is not enforced
+func diskSpace(string) (free, total int64, err error) {
+	return 0, 0, errDiskSpaceUnknown
+}
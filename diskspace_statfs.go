@@ -0,0 +1,15 @@
+//go:build darwin || dragonfly || freebsd || linux
+// +build darwin dragonfly freebsd linux
+
+package rotate
+
+import "syscall"
+
+// diskSpace return the bytes available to unprivileged users and the size of the filesystem of dir
+func diskSpace(dir string) (free, total int64, err error) {
+	var st syscall.Statfs_t
+	if err = syscall.Statfs(dir, &st); err != nil {
+		return 0, 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), int64(st.Blocks) * int64(st.Bsize), nil
+}
@@ -0,0 +1,135 @@
+package rotate
+
+import "time"
+
+type (
+	// Event is one of RotateStarted, RotateCompleted, CompressCompleted, CleanupCompleted,
+	// BackgroundError and MirrorFailed
+	Event interface {
+		labeled(labels map[string]string) Event
+	}
+
+	// RotateStarted is sent before the active file is closed
+	RotateStarted struct {
+		File   string // the active file
+		Size   int64  // bytes written to the active file
+		Time   time.Time
+		Labels map[string]string // see WithLabels
+	}
+
+	// RotateCompleted is sent once the active file is renamed to a backup, before it is compressed
+	RotateCompleted struct {
+		Backup   string
+		Start    time.Time // when the file became the active file
+		End      time.Time // when the file was rotated
+		Duration time.Duration
+		Labels   map[string]string
+	}
+
+	// CompressCompleted is sent once a backup is compressed
+	CompressCompleted struct {
+		Original string
+		Backup   string // the compressed backup
+		Size     int64  // size of the compressed backup
+		Duration time.Duration
+		Labels   map[string]string
+	}
+
+	// CleanupCompleted is sent after every retention and quota pass
+	CleanupCompleted struct {
+		Deleted  int64 // backups removed by the pass
+		Duration time.Duration
+		Err      error // set if the pass timed out
+		Labels   map[string]string
+	}
+
+	// BackgroundError is sent for every error also reported on the next Write
+	BackgroundError struct {
+		Err    error
+		Labels map[string]string
+	}
+
+	// MirrorFailed is sent when the mirror of WithMirror fails to open, write, rotate or sync,
+	// the primary keeps working
+	MirrorFailed struct {
+		Err    error
+		Labels map[string]string
+	}
+)
+
+// Listen call fn in order for every event, in a goroutine of its own so fn never blocks the writer.
+// Events are dropped while fn lags behind by more than subscriberBufSize events. The listener
+// stops after cancel or when the writer is closed.
+func (r *RotateWriter) Listen(fn func(Event)) func() {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	ch := make(chan Event, subscriberBufSize)
+	go func() {
+		for e := range ch {
+			fn(e)
+		}
+	}()
+	if r.done.Load() {
+		close(ch)
+		return func() {}
+	}
+	if r.listeners == nil {
+		r.listeners = make(map[int]chan Event)
+	}
+	id := r.nextSubID
+	r.nextSubID++
+	r.listeners[id] = ch
+	return func() {
+		r.subMu.Lock()
+		defer r.subMu.Unlock()
+		if _, ok := r.listeners[id]; ok {
+			delete(r.listeners, id)
+			close(ch)
+		}
+	}
+}
+
+// emit
+func (r *RotateWriter) emit(e Event) {
+	if len(r.opt.labels) > 0 {
+		e = e.labeled(r.opt.labels)
+	}
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	for _, ch := range r.listeners {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+func (e RotateStarted) labeled(labels map[string]string) Event {
+	e.Labels = labels
+	return e
+}
+
+func (e RotateCompleted) labeled(labels map[string]string) Event {
+	e.Labels = labels
+	return e
+}
+
+func (e CompressCompleted) labeled(labels map[string]string) Event {
+	e.Labels = labels
+	return e
+}
+
+func (e CleanupCompleted) labeled(labels map[string]string) Event {
+	e.Labels = labels
+	return e
+}
+
+func (e BackgroundError) labeled(labels map[string]string) Event {
+	e.Labels = labels
+	return e
+}
+
+func (e MirrorFailed) labeled(labels map[string]string) Event {
+	e.Labels = labels
+	return e
+}
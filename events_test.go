@@ -0,0 +1,71 @@
+package rotate
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_Listen(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	var (
+		mu     sync.Mutex
+		events []Event
+	)
+	cancel := writer.Listen(func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	})
+	defer cancel()
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancelWait := context.WithTimeout(context.Background(), time.Second)
+	defer cancelWait()
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	writer.setErr(errors.New("background"))
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 5 {
+		t.Fatalf("events incorrect, got:%#v", events)
+	}
+	if e, ok := events[0].(RotateStarted); !ok || e.Size != 5 {
+		t.Errorf("started incorrect, got:%#v", events[0])
+	}
+	completed, ok := events[1].(RotateCompleted)
+	if !ok {
+		t.Fatalf("completed incorrect, got:%#v", events[1])
+	}
+	if e, ok := events[2].(CompressCompleted); !ok || e.Original != completed.Backup || e.Backup != completed.Backup+".gz" || e.Size == 0 {
+		t.Errorf("compressed incorrect, got:%#v", events[2])
+	}
+	if _, ok := events[3].(CleanupCompleted); !ok {
+		t.Errorf("cleanup incorrect, got:%#v", events[3])
+	}
+	if e, ok := events[4].(BackgroundError); !ok || e.Err.Error() != "background" {
+		t.Errorf("error incorrect, got:%#v", events[4])
+	}
+}
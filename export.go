@@ -0,0 +1,104 @@
+package rotate
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"go.uber.org/multierr"
+	"io"
+	"os"
+	"strings"
+)
+
+// Export write a tar.gz archive with all backups and the live file to w. Backup names and the
+// live file are captured while rotation is paused, after the write buffer is flushed, and
+// retention does not run until the archive is written, so the archive is a consistent snapshot.
+func (r *RotateWriter) Export(ctx context.Context, w io.Writer) (err error) {
+	release, err := r.holdCleanup(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	r.mu.Lock()
+	if err = r.flushBuffer(); err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	backups, err := r.Backups()
+	if err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	live, err := os.Open(r.filename)
+	if err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	liveInfo, err := live.Stat()
+	r.mu.Unlock()
+	defer func() {
+		err = multierr.Append(err, live.Close())
+	}()
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, b := range backups {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if err = r.exportBackup(tw, b.Name); err != nil {
+			return err
+		}
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	if err = r.exportFile(tw, live, liveInfo); err != nil {
+		return err
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// exportBackup add name to tw, a plain backup compressed after it was listed is added as name.gz
+func (r *RotateWriter) exportBackup(tw *tar.Writer, name string) (err error) {
+	fp, err := os.Open(name)
+	if os.IsNotExist(err) && !strings.HasSuffix(name, ".gz") {
+		fp, err = os.Open(name + ".gz")
+	}
+	if os.IsNotExist(err) {
+		// removed by retention after the names were listed
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Append(err, fp.Close())
+	}()
+
+	fi, err := fp.Stat()
+	if err != nil {
+		return err
+	}
+	return r.exportFile(tw, fp, fi)
+}
+
+// exportFile copy fi.Size() bytes of fp under its path relative to the log directory, data
+// appended after the snapshot is left out
+func (r *RotateWriter) exportFile(tw *tar.Writer, fp *os.File, fi os.FileInfo) error {
+	hdr, err := tar.FileInfoHeader(fi, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = r.relName(fp.Name())
+	if err = tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, io.LimitReader(fp, fi.Size()))
+	return err
+}
@@ -0,0 +1,125 @@
+package rotate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_Export(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(tmpFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backupName := writer.backupName
+	if _, err := writer.Write([]byte("first\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(backupName)
+	if _, err := writer.Write([]byte("second\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := writer.Export(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	got := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[hdr.Name] = string(data)
+	}
+	if len(got) != 2 || got[filepath.Base(backupName)] != "first\n" || got[filepath.Base(tmpFileName)] != "second\n" {
+		t.Errorf("archive incorrect, got:%v", got)
+	}
+}
+
+func TestRotateWriter_Export_snapshot(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "app.log")
+	writer, err := NewRotateWriter(filename, WithAdaptiveBuffer(1<<20, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	// bytes the buffer holds back from the file
+	writer.mu.Lock()
+	writer.buffer.data = append(writer.buffer.data, "buffered\n"...)
+	writer.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var buf bytes.Buffer
+	if err := writer.Export(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if writer.cleaning.Load() {
+		t.Error("cleanup should run again after the export")
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	hdr, err := tr.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(tr)
+	if err != nil || hdr.Name != "app.log" || string(data) != "buffered\n" {
+		t.Errorf("live file incorrect, got:%s %q %v", hdr.Name, data, err)
+	}
+
+	// a running cleanup pass is waited for
+	writer.cleaning.Store(true)
+	short, cancelShort := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelShort()
+	if err := writer.Export(short, ioutil.Discard); err != context.DeadlineExceeded {
+		t.Errorf("export should wait for the cleanup, got:%v", err)
+	}
+	writer.cleaning.Store(false)
+}
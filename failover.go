@@ -0,0 +1,122 @@
+package rotate
+
+import (
+	"errors"
+	"go.uber.org/multierr"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	failoverAfter        = 3 // consecutive I/O failures before switching to the failover directory
+	defaultFailoverProbe = 10 * time.Second
+)
+
+// WithFailoverDir switch the active file to dir when writes or rotations to the primary path keep
+// failing with I/O errors, e.g. after a read-only remount or with ErrDiskFull. The primary directory
+// is probed every probe, default to 10s, and the writer switch back once it is writable again.
+// Subscribers receive an event with Active set on both switches.
+func WithFailoverDir(dir string, probe time.Duration) RotateOption {
+	return func(o *rotateOption) {
+		o.failoverDir = dir
+		o.failoverProbe = probe
+		if probe <= 0 {
+			o.failoverProbe = defaultFailoverProbe
+		}
+	}
+}
+
+// noteResult count the consecutive I/O failures and fail over past failoverAfter, the lock must be held
+func (r *RotateWriter) noteResult(err error) {
+	if err == nil {
+		r.failures = 0
+		return
+	}
+	if len(r.opt.failoverDir) == 0 || len(r.primary) > 0 || !ioFailure(err) {
+		return
+	}
+	r.failures++
+	if r.failures < failoverAfter {
+		return
+	}
+	r.failures = 0
+	primary := r.filename
+	target := filepath.Join(r.opt.failoverDir, filepath.Base(primary))
+	if switchErr := r.switchFile(target); switchErr != nil {
+		r.setErr(switchErr)
+		return
+	}
+	r.primary = primary
+	r.publish(RotateEvent{Active: target, Start: r.openedAt, Err: err})
+	go r.probePrimary(primary)
+}
+
+// probePrimary switch back to primary once its directory is writable
+func (r *RotateWriter) probePrimary(primary string) {
+	ticker := time.NewTicker(r.opt.failoverProbe)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !writable(filepath.Dir(primary)) {
+				continue
+			}
+			r.mu.Lock()
+			if r.done.Load() {
+				r.mu.Unlock()
+				return
+			}
+			if err := r.switchFile(primary); err != nil {
+				r.setErr(err)
+				r.mu.Unlock()
+				continue
+			}
+			r.primary = ""
+			r.publish(RotateEvent{Active: primary, Start: r.openedAt})
+			r.mu.Unlock()
+			return
+		case <-r.postDone:
+			return
+		}
+	}
+}
+
+// switchFile move the active file to filename, the current file may be on a failing filesystem
+// so its flush and close errors are ignored. The lock must be held.
+func (r *RotateWriter) switchFile(filename string) error {
+	_ = r.flushBuffer()
+	r.waitFlush()
+	_ = r.fp.Close()
+	old := r.filename
+	r.setFilename(filename)
+	r.partial = false
+	if err := r.init(); err != nil {
+		r.setFilename(old)
+		return multierr.Append(err, r.init())
+	}
+	return nil
+}
+
+// ioFailure report whether err come from the filesystem
+func ioFailure(err error) bool {
+	var (
+		pathErr    *os.PathError
+		linkErr    *os.LinkError
+		syscallErr *os.SyscallError
+	)
+	return errors.Is(err, ErrDiskFull) || errors.As(err, &pathErr) || errors.As(err, &linkErr) ||
+		errors.As(err, &syscallErr)
+}
+
+// writable try to create, write and remove a file in dir
+func writable(dir string) bool {
+	fp, err := ioutil.TempFile(dir, ".rotate-probe")
+	if err != nil {
+		return false
+	}
+	_, err = fp.Write([]byte{'\n'})
+	err = multierr.Combine(err, fp.Sync(), fp.Close(), os.Remove(fp.Name()))
+	return err == nil
+}
@@ -0,0 +1,72 @@
+package rotate
+
+import (
+	"errors"
+	"go.uber.org/atomic"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_WithFailoverDir(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "failover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	primary, failover := filepath.Join(dir, "primary", "app.log"), filepath.Join(dir, "failover")
+	var (
+		writer *RotateWriter
+		broken atomic.Bool
+	)
+	// fail the writes to the primary file while broken is set
+	readOnly := func(next WriteFunc) WriteFunc {
+		return func(data []byte) error {
+			if filename, _, _ := writer.paths(); broken.Load() && filename == primary {
+				return &os.PathError{Op: "write", Path: filename, Err: errors.New("read-only file system")}
+			}
+			return next(data)
+		}
+	}
+	writer, err = NewRotateWriter(primary, WithMiddleware(readOnly), WithFailoverDir(failover, 200*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	events, cancel := writer.Subscribe()
+	defer cancel()
+
+	broken.Store(true)
+	for i := 0; i < failoverAfter; i++ {
+		if _, err := writer.Write([]byte("lost\n")); err == nil {
+			t.Fatal("write should fail")
+		}
+	}
+	if e := <-events; e.Active != filepath.Join(failover, "app.log") || e.Err == nil {
+		t.Errorf("failover event incorrect, got:%+v", e)
+	}
+	if _, err := writer.Write([]byte("failover\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	broken.Store(false)
+	select {
+	case e := <-events:
+		if e.Active != primary || e.Err != nil {
+			t.Errorf("failback event incorrect, got:%+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("writer should fail back to the primary directory")
+	}
+	if _, err := writer.Write([]byte("primary\n")); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{primary: "primary\n", filepath.Join(failover, "app.log"): "failover\n"} {
+		if data, err := ioutil.ReadFile(name); err != nil || string(data) != want {
+			t.Errorf("%s incorrect, got:%q %v", name, data, err)
+		}
+	}
+}
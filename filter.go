@@ -0,0 +1,132 @@
+package rotate
+
+import (
+	"bytes"
+	"fmt"
+	"go.uber.org/multierr"
+	"hash/fnv"
+	"time"
+)
+
+const defaultFilterFlush = 10 * time.Second
+
+// filterState is only touched while holding the writer lock
+type filterState struct {
+	last       []byte // last record written, for dedup
+	repeated   int64
+	counts     map[uint64]int // records seen per sampling tick, by hash
+	suppressed int64          // records suppressed by sampling since the last summary
+	tickStart  time.Time
+}
+
+// WithDedup suppress records identical to the previous one, a "last message repeated N times"
+// line is written before the next different record or at least every flush
+func WithDedup(flush time.Duration) RotateOption {
+	return func(o *rotateOption) {
+		if flush <= 0 {
+			flush = defaultFilterFlush
+		}
+		o.dedup = true
+		o.dedupFlush = flush
+	}
+}
+
+// WithSampling write the first records of every identical message per tick, then only every
+// thereafter-th one, a summary line with the suppressed count is written every tick
+func WithSampling(first, thereafter int, tick time.Duration) RotateOption {
+	return func(o *rotateOption) {
+		if tick <= 0 {
+			tick = defaultFilterFlush
+		}
+		o.sampleFirst = first
+		o.sampleThereafter = thereafter
+		o.sampleTick = tick
+	}
+}
+
+// filterInterval return how often summaries are flushed, zero if no filter is configured
+func (o *rotateOption) filterInterval() time.Duration {
+	switch {
+	case o.dedup && o.sampleTick > 0 && o.sampleTick < o.dedupFlush:
+		return o.sampleTick
+	case o.dedup:
+		return o.dedupFlush
+	default:
+		return o.sampleTick
+	}
+}
+
+// suppress report whether data is filtered out, it must be called while holding the lock
+func (r *RotateWriter) suppress(data []byte) (bool, error) {
+	if r.opt.dedup {
+		if r.filter.last != nil && bytes.Equal(r.filter.last, data) {
+			r.filter.repeated++
+			return true, nil
+		}
+		if err := r.flushRepeated(); err != nil {
+			return false, err
+		}
+		r.filter.last = append(r.filter.last[:0], data...)
+	}
+	if r.opt.sampleTick > 0 {
+		now := time.Now()
+		if r.filter.counts == nil || now.Sub(r.filter.tickStart) >= r.opt.sampleTick {
+			r.filter.counts = make(map[uint64]int)
+			r.filter.tickStart = now
+		}
+		h := fnv.New64a()
+		_, _ = h.Write(data)
+		key := h.Sum64()
+		r.filter.counts[key]++
+		n := r.filter.counts[key]
+		if n > r.opt.sampleFirst && (r.opt.sampleThereafter <= 0 || (n-r.opt.sampleFirst)%r.opt.sampleThereafter != 0) {
+			r.filter.suppressed++
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// flushRepeated
+func (r *RotateWriter) flushRepeated() error {
+	if r.filter.repeated == 0 {
+		return nil
+	}
+	msg := r.notice(fmt.Sprintf("last message repeated %d times", r.filter.repeated),
+		map[string]int64{"repeated": r.filter.repeated})
+	r.stats.suppressed.Add(r.filter.repeated)
+	r.filter.repeated = 0
+	return r.writeRecord(msg)
+}
+
+// flushSuppressed
+func (r *RotateWriter) flushSuppressed() error {
+	if r.filter.suppressed == 0 {
+		return nil
+	}
+	msg := r.notice(fmt.Sprintf("sampling suppressed %d records", r.filter.suppressed),
+		map[string]int64{"suppressed": r.filter.suppressed})
+	r.stats.suppressed.Add(r.filter.suppressed)
+	r.filter.suppressed = 0
+	return r.writeRecord(msg)
+}
+
+// flushFilters write pending summaries periodically
+func (r *RotateWriter) flushFilters() {
+	ticker := time.NewTicker(r.opt.filterInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.mu.Lock()
+			if !r.done.Load() {
+				if err := multierr.Append(r.flushRepeated(), r.flushSuppressed()); err != nil {
+					r.setErr(err)
+				}
+			}
+			r.mu.Unlock()
+		case <-r.postDone:
+			return
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_dedup(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(tmpFileName, WithDedup(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range []string{"a\n", "a\n", "a\n", "b\n", "b\n"} {
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(tmpFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a\nlast message repeated 2 times\nb\nlast message repeated 1 times\n"
+	if string(got) != want {
+		t.Errorf("dedup content incorrect, got:%q, want:%q", got, want)
+	}
+	if stats := writer.Stats(); stats.Suppressed != 3 {
+		t.Errorf("suppressed count incorrect, got:%v", stats.Suppressed)
+	}
+}
+
+func TestRotateWriter_sampling(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(tmpFileName, WithSampling(2, 3, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 8; i++ {
+		if _, err := writer.Write([]byte("x\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(tmpFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1st, 2nd and 5th, 8th records are kept
+	want := "x\nx\nx\nx\nsampling suppressed 4 records\n"
+	if string(got) != want {
+		t.Errorf("sampled content incorrect, got:%q, want:%q", got, want)
+	}
+}
+
+func TestRotateWriter_dedup_jsonLines(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "dedup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "app.log")
+	writer, err := NewRotateWriter(filename, WithDedup(time.Hour), WithJSONLines(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range []string{`{"a":1}` + "\n", `{"a":1}` + "\n", `{"b":2}` + "\n"} {
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"a":1}` + "\n" + `{"msg":"last message repeated 1 times","repeated":1}` + "\n" + `{"b":2}` + "\n"
+	if string(got) != want {
+		t.Errorf("dedup content incorrect, got:%q, want:%q", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package rotate
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	followInterval = 100 * time.Millisecond
+	followBufSize  = 32 * 1024
+)
+
+// Follow stream data appended to the active file from now on like tail -F, it keeps
+// following the new active file after rotation. The channel is closed when ctx is done.
+func (r *RotateWriter) Follow(ctx context.Context) (<-chan []byte, error) {
+	filename, _, _ := r.paths()
+	fp, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = fp.Seek(0, io.SeekEnd); err != nil {
+		_ = fp.Close()
+		return nil, err
+	}
+	ch := make(chan []byte)
+	go r.follow(ctx, fp, ch)
+	return ch, nil
+}
+
+// follow
+func (r *RotateWriter) follow(ctx context.Context, fp *os.File, ch chan<- []byte) {
+	defer close(ch)
+	defer func() {
+		_ = fp.Close()
+	}()
+
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+	buf := make([]byte, followBufSize)
+	for {
+		if !drainFollow(ctx, fp, buf, ch) {
+			return
+		}
+
+		// switch to the new active file once the old one is fully read, again since the
+		// last writes may land right before the rotation was seen
+		if next, ok := r.reopenIfRotated(fp); ok {
+			if !drainFollow(ctx, fp, buf, ch) {
+				_ = next.Close()
+				return
+			}
+			_ = fp.Close()
+			fp = next
+			continue
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// drainFollow send what was written to fp so far, false once ctx is done
+func drainFollow(ctx context.Context, fp *os.File, buf []byte, ch chan<- []byte) bool {
+	for {
+		n, err := fp.Read(buf)
+		if n > 0 {
+			select {
+			case ch <- append([]byte(nil), buf[:n]...):
+			case <-ctx.Done():
+				return false
+			}
+		}
+		if err != nil {
+			return true
+		}
+	}
+}
+
+// reopenIfRotated open the active file if it is not fp anymore, or rewind fp if it was truncated
+func (r *RotateWriter) reopenIfRotated(fp *os.File) (*os.File, bool) {
+	cur, err := fp.Stat()
+	if err != nil {
+		return nil, false
+	}
+	filename, _, _ := r.paths()
+	active, err := os.Stat(filename)
+	if err != nil {
+		// between rename and create
+		return nil, false
+	}
+	if os.SameFile(cur, active) {
+		if offset, err := fp.Seek(0, io.SeekCurrent); err == nil && active.Size() < offset {
+			_, _ = fp.Seek(0, io.SeekStart)
+		}
+		return nil, false
+	}
+	next, err := os.Open(filename)
+	if err != nil {
+		return nil, false
+	}
+	return next, true
+}
@@ -0,0 +1,108 @@
+package rotate
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_Follow(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if _, err := tmpFile.WriteString("old\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(tmpFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backupName := writer.backupName
+	defer os.Remove(backupName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ch, err := writer.Follow(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := writer.Write([]byte("first\n")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * followInterval)
+	if _, err := writer.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte("second\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	for data := range ch {
+		got += string(data)
+		if got == "first\nsecond\n" {
+			return
+		}
+	}
+	t.Fatalf("followed content incorrect, got:%q", got)
+}
+
+func TestRotateWriter_Follow_writeBeforeRotate(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "follow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithClock(&stepClock{now: time.Now()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ch, err := writer.Follow(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no pause between the write and the rotation, the follower must still read the line
+	var got string
+	for i := 0; i < 20; i++ {
+		before, after := fmt.Sprintf("before %d\n", i), fmt.Sprintf("after %d\n", i)
+		if _, err := writer.Write([]byte(before)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(after)); err != nil {
+			t.Fatal(err)
+		}
+		for got != before+after {
+			data, ok := <-ch
+			if !ok {
+				t.Fatalf("followed content incorrect, got:%q", got)
+			}
+			got += string(data)
+		}
+		got = ""
+	}
+}
@@ -0,0 +1,100 @@
+package rotate
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Framing is the record framing of the written files
+type Framing int
+
+const (
+	// FramingNone write records as they are
+	FramingNone Framing = iota
+	// FramingUvarint prefix every record with its length as an unsigned varint
+	FramingUvarint
+	// FramingUint32 prefix every record with its length as a big endian uint32
+	FramingUint32
+)
+
+var ErrFrameTooLarge = errors.New("error: frame exceeds maximum")
+
+// WithFraming prefix every record with its length, a record is never split across files.
+// Header and footer hooks write raw bytes, they must write frames themselves.
+func WithFraming(f Framing) RotateOption {
+	return func(o *rotateOption) {
+		o.framing = f
+	}
+}
+
+// appendFrame append the framed data to buf
+func appendFrame(buf []byte, f Framing, data []byte) []byte {
+	switch f {
+	case FramingUvarint:
+		var prefix [binary.MaxVarintLen64]byte
+		n := binary.PutUvarint(prefix[:], uint64(len(data)))
+		buf = append(buf, prefix[:n]...)
+	case FramingUint32:
+		var prefix [4]byte
+		binary.BigEndian.PutUint32(prefix[:], uint32(len(data)))
+		buf = append(buf, prefix[:]...)
+	}
+	return append(buf, data...)
+}
+
+// frameOverhead return the largest length prefix of f
+func frameOverhead(f Framing) int64 {
+	switch f {
+	case FramingUvarint:
+		return binary.MaxVarintLen64
+	case FramingUint32:
+		return 4
+	}
+	return 0
+}
+
+// FrameReader read the records of a framed file
+type FrameReader struct {
+	r       *bufio.Reader
+	framing Framing
+	max     int
+}
+
+// NewFrameReader read frames of at most max bytes from r
+func NewFrameReader(r io.Reader, f Framing, max int) *FrameReader {
+	return &FrameReader{r: bufio.NewReader(r), framing: f, max: max}
+}
+
+// Next return the next record, io.EOF after the last one and io.ErrUnexpectedEOF for a truncated frame
+func (fr *FrameReader) Next() ([]byte, error) {
+	var size uint64
+	switch fr.framing {
+	case FramingUvarint:
+		n, err := binary.ReadUvarint(fr.r)
+		if err != nil {
+			return nil, err
+		}
+		size = n
+	case FramingUint32:
+		var prefix [4]byte
+		if _, err := io.ReadFull(fr.r, prefix[:]); err != nil {
+			return nil, err
+		}
+		size = uint64(binary.BigEndian.Uint32(prefix[:]))
+	default:
+		return nil, errors.New("error: reading unframed data")
+	}
+	if size > uint64(fr.max) {
+		return nil, ErrFrameTooLarge
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(fr.r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
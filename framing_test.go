@@ -0,0 +1,61 @@
+package rotate
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestRotateWriter_framing(t *testing.T) {
+	for _, framing := range []Framing{FramingUvarint, FramingUint32} {
+		tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpFileName := tmpFile.Name()
+		if err := tmpFile.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		writer, err := NewRotateWriter(tmpFileName, WithFraming(framing))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := [][]byte{[]byte("first"), {}, []byte("line\nwith newline")}
+		for _, data := range want {
+			if _, err := writer.Write(data); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		fp, err := os.Open(tmpFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fr := NewFrameReader(fp, framing, megabyte)
+		got := make([][]byte, 0)
+		for {
+			data, err := fr.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, data)
+		}
+		if err := fp.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("framing %v records incorrect, got:%q", framing, got)
+		}
+	}
+}
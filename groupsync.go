@@ -0,0 +1,61 @@
+package rotate
+
+// WithSyncEveryWrite make Write return only once its data is flushed to disk. Concurrent writers
+// share one flush, each flush covers every write appended before it (group commit).
+func WithSyncEveryWrite(enable bool) RotateOption {
+	return func(o *rotateOption) {
+		o.syncEveryWrite = enable
+	}
+}
+
+// commit flush the active file unless a flush since the write seq already covered it. The flush
+// runs without the lock so writes keep appending meanwhile and are covered by the next one.
+func (r *RotateWriter) commit(seq uint64) error {
+	r.syncMu.Lock()
+	defer r.syncMu.Unlock()
+	if r.syncedSeq.Load() >= seq {
+		return nil
+	}
+
+	r.mu.Lock()
+	if r.syncedSeq.Load() >= seq {
+		// flushed by a rotation or Sync
+		r.mu.Unlock()
+		return nil
+	}
+	if r.done.Load() {
+		r.mu.Unlock()
+		return ErrLogFileClosed
+	}
+	if err := r.flushBuffer(); err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	fp, target := r.fp, r.writeSeq
+	// the file is not closed before the flush is over, see waitFlush
+	r.flushing.Add(1)
+	r.mu.Unlock()
+
+	err := r.flush(fp)
+	r.flushing.Done()
+	if err != nil {
+		return err
+	}
+	r.setSynced(target)
+	return nil
+}
+
+// waitFlush wait for the flush in flight before the active file is closed, the lock must be held
+func (r *RotateWriter) waitFlush() {
+	r.flushing.Wait()
+}
+
+// setSynced record that the writes up to seq are on disk
+func (r *RotateWriter) setSynced(seq uint64) {
+	for {
+		synced := r.syncedSeq.Load()
+		if synced >= seq || r.syncedSeq.CAS(synced, seq) {
+			return
+		}
+	}
+}
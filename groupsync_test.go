@@ -0,0 +1,50 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestRotateWriter_WithSyncEveryWrite(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "groupsync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithSyncEveryWrite(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	if syncs := writer.Stats().Syncs; syncs != 1 {
+		t.Errorf("a single write should be synced, got:%d", syncs)
+	}
+
+	const writers, writes = 20, 50
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				if _, err := writer.Write([]byte("test\n")); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	stats := writer.Stats()
+	if stats.Syncs > stats.Writes || writer.syncedSeq.Load() != writer.writeSeq {
+		t.Errorf("every write should be synced, got:%+v", stats)
+	}
+	t.Logf("%d writes, %d syncs", stats.Writes, stats.Syncs)
+}
@@ -0,0 +1,102 @@
+package rotate
+
+import (
+	"context"
+	"go.uber.org/multierr"
+	"os"
+	"time"
+)
+
+// WithDailyGzip append every backup as one more gzip member to a single backup per day instead
+// of one .gz per rotation, named after the midnight of the day, e.g. app-2024-01-02T00:00:00Z.log.gz.
+// RFC 1952 allows several members in one file, zcat and the readers of this package read them
+// in order. It requires WithGzip. The daily backup is indexed once, when it is created, and
+// uploaded once its day is over, on the first rotation of the next day.
+func WithDailyGzip(enable bool) RotateOption {
+	return func(o *rotateOption) {
+		o.dailyGzip = enable
+	}
+}
+
+// dailyName return the daily backup collecting filename
+func (r *RotateWriter) dailyName(filename string) string {
+	_, prefix, ext := r.paths()
+	return prefix + r.opt.delimiter + nowDate(r.dailyStart(filename), r.opt.timeFormat, r.opt.localTime) + ext + ".gz"
+}
+
+// dailyStart return the midnight starting the day of filename
+func (r *RotateWriter) dailyStart(filename string) time.Time {
+	ts, err := r.parseBackupTime(filename)
+	if err != nil {
+		ts = r.now()
+	}
+	if !r.opt.localTime {
+		ts = ts.UTC()
+	}
+	y, m, d := ts.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, ts.Location())
+}
+
+// trackDaily remember b, appended to the daily backup of its day, and index that backup when b
+// created it. It return the finished daily backup of the day before, if any, only called by afterRotate
+func (r *RotateWriter) trackDaily(plain string, b backup) (finished backup) {
+	if r.daily.name == b.name {
+		r.daily.end = b.end
+		return backup{}
+	}
+	finished, r.daily = r.daily, b
+	// the span covers the whole day the daily backup collects
+	if err := r.indexAdd(b.name, b.start, r.dailyStart(plain).AddDate(0, 0, 1)); err != nil {
+		r.setErr(err)
+	}
+	return finished
+}
+
+// appendGzip append filename to target as a new gzip member, a failed or cancelled append is
+// truncated away so target stays readable
+func (r *RotateWriter) appendGzip(ctx context.Context, filename, target string) (err error) {
+	r.dailyMu.Lock()
+	defer r.dailyMu.Unlock()
+	in, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Append(err, in.Close())
+	}()
+	// the backup permissions and attribute are set again once the backup is processed
+	if err = r.unprotectDaily(target); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_APPEND|os.O_WRONLY, defaultFilePerm)
+	if err != nil {
+		return err
+	}
+	fi, err := out.Stat()
+	if err != nil {
+		return multierr.Append(err, out.Close())
+	}
+	err = multierr.Append(r.gzipSettings().copy(ctx, out, in), out.Sync())
+	if err != nil {
+		err = multierr.Append(err, out.Truncate(fi.Size()))
+	}
+	if err = multierr.Append(err, out.Close()); err != nil || r.opt.keepOriginal {
+		return err
+	}
+	return os.Remove(filename)
+}
+
+// unprotectDaily make the daily backup writable again
+func (r *RotateWriter) unprotectDaily(target string) error {
+	if r.opt.backupAttr != AttrNone {
+		if err := setBackupAttr(target, r.opt.backupAttr, false); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if r.opt.backupPerm != 0 {
+		if err := os.Chmod(target, defaultFilePerm); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
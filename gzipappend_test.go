@@ -0,0 +1,128 @@
+package rotate
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_WithDailyGzip(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "daily")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clock := &stepClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithDailyGzip(true),
+		WithLocalTime(false), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for _, data := range []string{"a\n", "b\n", "c\n"} {
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	daily := filepath.Join(dir, "app-2024-01-01T00:00:00Z.log.gz")
+	if len(backups) != 1 || backups[0].Name != daily {
+		t.Fatalf("backups incorrect, got:%v", backups)
+	}
+	fp, err := os.Open(daily)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+	br := bufio.NewReader(fp)
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var members []string
+	for {
+		gz.Multistream(false)
+		data, err := ioutil.ReadAll(gz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		members = append(members, string(data))
+		if err = gz.Reset(br); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(members) != 3 || members[0] != "a\n" || members[2] != "c\n" {
+		t.Errorf("members incorrect, got:%q", members)
+	}
+}
+
+func TestRotateWriter_WithDailyGzip_once(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "daily")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clock := &stepClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	uploader := &mockUploader{}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithDailyGzip(true),
+		WithLocalTime(false), WithClock(clock), WithIndex(""), WithUploader(uploader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, day := range []int{1, 2} {
+		clock.mu.Lock()
+		clock.now = time.Date(2024, 1, day, 12, 0, 0, 0, time.UTC)
+		clock.mu.Unlock()
+		for _, data := range []string{"a\n", "b\n", "c\n"} {
+			if _, err := writer.Write([]byte(data)); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := writer.Rotate(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := writer.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first := filepath.Join(dir, "app-2024-01-01T00:00:00Z.log.gz")
+	indexed, err := writer.Query(time.Time{}, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indexed) != 2 || indexed[0].Name != first {
+		t.Errorf("index incorrect, got:%+v", indexed)
+	}
+	// the first day is shipped once the second one starts, the second is still collecting
+	uploader.mu.Lock()
+	defer uploader.mu.Unlock()
+	if len(uploader.files) != 1 || uploader.files[0] != first {
+		t.Errorf("uploads incorrect, got:%v", uploader.files)
+	}
+}
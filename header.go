@@ -0,0 +1,53 @@
+package rotate
+
+import "io"
+
+// fileWriter write to the active file with size accounting, only use it while holding the lock
+type fileWriter struct {
+	r *RotateWriter
+}
+
+// Write
+func (w fileWriter) Write(p []byte) (int, error) {
+	if err := w.r.writeFile(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WithHeader call fn at the top of every new active file, at init and after rotation, e.g. to write
+// a csv header row, the header bytes count toward the size limit
+func WithHeader(fn func(w io.Writer) error) RotateOption {
+	return func(o *rotateOption) {
+		o.header = fn
+	}
+}
+
+// writeHeader
+func (r *RotateWriter) writeHeader() error {
+	if err := r.writeChainStart(); err != nil {
+		return err
+	}
+	if r.opt.header == nil {
+		return nil
+	}
+	return r.opt.header(fileWriter{r: r})
+}
+
+// WithFooter call fn at the end of the active file just before it is renamed to a backup,
+// e.g. to write an end-of-file sentinel
+func WithFooter(fn func(w io.Writer) error) RotateOption {
+	return func(o *rotateOption) {
+		o.footer = fn
+	}
+}
+
+// writeFooter
+func (r *RotateWriter) writeFooter() error {
+	if r.opt.footer != nil {
+		if err := r.opt.footer(fileWriter{r: r}); err != nil {
+			return err
+		}
+	}
+	return r.writeChainEnd()
+}
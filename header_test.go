@@ -0,0 +1,123 @@
+package rotate
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotateWriter_header(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	header := "id,name\n"
+	writer, err := NewRotateWriter(tmpFileName, WithHeader(func(w io.Writer) error {
+		_, err := io.WriteString(w, header)
+		return err
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backupName := writer.backupName
+	if writer.size != int64(len(header)) {
+		t.Errorf("header should count toward size, got:%v", writer.size)
+	}
+
+	if _, err := writer.Write([]byte("1,a\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(backupName)
+
+	for name, want := range map[string]string{backupName: header + "1,a\n", tmpFileName: header} {
+		got, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s content incorrect, got:%q, want:%q", name, got, want)
+		}
+	}
+}
+
+func TestRotateWriter_footer(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	footer := "#EOF\n"
+	writer, err := NewRotateWriter(tmpFileName, WithFooter(func(w io.Writer) error {
+		_, err := io.WriteString(w, footer)
+		return err
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backupName := writer.backupName
+
+	if _, err := writer.Write([]byte("data\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(backupName)
+
+	for name, want := range map[string]string{backupName: "data\n" + footer, tmpFileName: ""} {
+		got, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s content incorrect, got:%q, want:%q", name, got, want)
+		}
+	}
+}
+
+func TestRotateWriter_existingSize(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "size")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+	if err := ioutil.WriteFile(filename, []byte("existing\n"), defaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if size := writer.Stats().ActiveSize; size != 9 {
+		t.Errorf("an existing file should count toward the size limit, got:%v", size)
+	}
+}
@@ -0,0 +1,162 @@
+package rotate
+
+import (
+	"bufio"
+	"encoding/json"
+	"go.uber.org/multierr"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultIndexSuffix = ".index.jsonl"
+	indexAdd           = "add"
+	indexRemove        = "remove"
+)
+
+// indexEntry is one line of the backup index
+type indexEntry struct {
+	Op         string    `json:"op"`
+	Name       string    `json:"name"`
+	Size       int64     `json:"size,omitempty"`
+	Compressed bool      `json:"compressed,omitempty"`
+	Start      time.Time `json:"start,omitempty"`
+	End        time.Time `json:"end,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// WithIndex record every backup the writer produce or remove in a JSONL index file,
+// default index is prefix.index.jsonl next to the logs, see Query
+func WithIndex(filename string) RotateOption {
+	return func(o *rotateOption) {
+		o.index = true
+		o.indexName = filename
+	}
+}
+
+// Query return the indexed backups holding data written between from and to, ordered
+// by start time, removed backups are included with Removed set. Timestamp is when the
+// backup was opened and ModTime when it was rotated.
+func (r *RotateWriter) Query(from, to time.Time) ([]BackupInfo, error) {
+	r.indexMu.Lock()
+	infos, err := r.readIndex()
+	r.indexMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]BackupInfo, 0, len(infos))
+	for _, info := range infos {
+		if !info.Timestamp.After(to) && !info.ModTime.Before(from) {
+			result = append(result, *info)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Timestamp.Equal(result[j].Timestamp) {
+			return result[i].Timestamp.Before(result[j].Timestamp)
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// readIndex return the indexed backups by name, indexMu must be held
+func (r *RotateWriter) readIndex() (_ map[string]*BackupInfo, err error) {
+	infos := make(map[string]*BackupInfo)
+	fp, err := os.Open(r.indexFileName())
+	if os.IsNotExist(err) {
+		return infos, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = multierr.Append(err, fp.Close())
+	}()
+
+	scanner := bufio.NewScanner(fp)
+	for scanner.Scan() {
+		var e indexEntry
+		if err = json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, err
+		}
+		switch e.Op {
+		case indexAdd:
+			infos[e.Name] = &BackupInfo{
+				Name:       e.Name,
+				Size:       e.Size,
+				ModTime:    e.End,
+				Compressed: e.Compressed,
+				Timestamp:  e.Start,
+			}
+		case indexRemove:
+			if info, ok := infos[e.Name]; ok {
+				info.Removed = true
+			}
+		}
+	}
+	return infos, scanner.Err()
+}
+
+// indexSpan return the start and end recorded for the backup name
+func (r *RotateWriter) indexSpan(name string) (start, end time.Time, ok bool, err error) {
+	if !r.opt.index {
+		return time.Time{}, time.Time{}, false, nil
+	}
+	r.indexMu.Lock()
+	infos, err := r.readIndex()
+	r.indexMu.Unlock()
+	if err != nil {
+		return time.Time{}, time.Time{}, false, err
+	}
+	info, ok := infos[name]
+	if !ok || info.Removed {
+		return time.Time{}, time.Time{}, false, nil
+	}
+	return info.Timestamp, info.ModTime, true, nil
+}
+
+// indexFileName
+func (r *RotateWriter) indexFileName() string {
+	if len(r.opt.indexName) == 0 {
+		_, prefix, _ := r.paths()
+		return prefix + defaultIndexSuffix
+	}
+	return r.opt.indexName
+}
+
+// indexAdd record a finished backup
+func (r *RotateWriter) indexAdd(name string, start, end time.Time) error {
+	if !r.opt.index {
+		return nil
+	}
+	fi, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	return r.appendIndex(indexEntry{
+		Op:         indexAdd,
+		Name:       name,
+		Size:       fi.Size(),
+		Compressed: strings.HasSuffix(name, ".gz") || r.recompressed(name),
+		Start:      start,
+		End:        end,
+	})
+}
+
+// indexRemove record a removed backup
+func (r *RotateWriter) indexRemove(name string) error {
+	if !r.opt.index {
+		return nil
+	}
+	return r.appendIndex(indexEntry{Op: indexRemove, Name: name})
+}
+
+// appendIndex
+func (r *RotateWriter) appendIndex(e indexEntry) error {
+	e.Time = r.now()
+	r.indexMu.Lock()
+	defer r.indexMu.Unlock()
+	return appendJSONLine(r.indexFileName(), e)
+}
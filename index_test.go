@@ -0,0 +1,61 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_Query(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithIndex(""),
+		WithMaxBackups(1), WithClock(&stepClock{now: time.Now()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	start := time.Now()
+	var names []string
+	for i := 0; i < 2; i++ {
+		names = append(names, writer.backupName+".gz")
+		if _, err := writer.Write([]byte("test\n")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	infos, err := writer.Query(start, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("query incorrect, got:%+v", infos)
+	}
+	for i, info := range infos {
+		if info.Name != names[i] || !info.Compressed || info.Size == 0 {
+			t.Errorf("backup %d incorrect, got:%+v", i, info)
+		}
+	}
+	if !infos[0].Removed || infos[1].Removed {
+		t.Errorf("the first backup should be removed by retention, got:%+v", infos)
+	}
+
+	if infos, err = writer.Query(start.Add(-time.Hour), start.Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	} else if len(infos) != 0 {
+		t.Errorf("query out of range incorrect, got:%+v", infos)
+	}
+}
@@ -0,0 +1,47 @@
+package rotate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidJSONLine = errors.New("error: data is not complete json lines")
+
+// WithJSONLines only rotate between newline-delimited records, a record written by several
+// Write calls stays in one file. If validate, every Write must be complete json lines.
+func WithJSONLines(validate bool) RotateOption {
+	return func(o *rotateOption) {
+		o.jsonLines = true
+		o.validateJSON = validate
+	}
+}
+
+// validateJSONLines check that data is one or more newline terminated json values
+func validateJSONLines(data []byte) error {
+	if len(data) == 0 || data[len(data)-1] != '\n' {
+		return ErrInvalidJSONLine
+	}
+	for _, line := range bytes.Split(data[:len(data)-1], []byte{'\n'}) {
+		if !json.Valid(line) {
+			return ErrInvalidJSONLine
+		}
+	}
+	return nil
+}
+
+// notice return a line the writer adds on its own, msg as is or, with WithJSONLines, a json object
+// with msg and the counts of fields so the file stays json lines
+func (r *RotateWriter) notice(msg string, fields map[string]int64) []byte {
+	if !r.opt.jsonLines {
+		return []byte(msg + "\n")
+	}
+	obj := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		obj[k] = v
+	}
+	obj["msg"] = msg
+	// a map of strings and integers always marshals
+	data, _ := json.Marshal(obj)
+	return append(data, '\n')
+}
@@ -0,0 +1,71 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestRotateWriter_jsonLines(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(tmpFileName, WithJSONLines(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backupName := writer.backupName
+	writer.opt.maxSize = 24
+
+	for _, data := range []string{`{"a":`, `"0123456789"}` + "\n" + `{"b":1}` + "\n"} {
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer os.Remove(backupName)
+
+	got, err := ioutil.ReadFile(backupName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `{"a":"0123456789"}`+"\n" {
+		t.Errorf("backup should end with the complete record, got:%q", got)
+	}
+	got, err = ioutil.ReadFile(tmpFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `{"b":1}`+"\n" {
+		t.Errorf("active file should start with a complete record, got:%q", got)
+	}
+}
+
+func TestValidateJSONLines(t *testing.T) {
+	tests := []struct {
+		data  string
+		valid bool
+	}{
+		{data: "{\"a\":1}\n", valid: true},
+		{data: "{\"a\":1}\n[2]\n", valid: true},
+		{data: "{\"a\":1}", valid: false},
+		{data: "{\"a\":\n", valid: false},
+		{data: "\n", valid: false},
+	}
+	for _, tt := range tests {
+		if err := validateJSONLines([]byte(tt.data)); (err == nil) != tt.valid {
+			t.Errorf("validate %q incorrect, got:%v", tt.data, err)
+		}
+	}
+}
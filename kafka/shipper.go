@@ -0,0 +1,158 @@
+// Package kafka provide a rotate.Uploader that stream the lines of each backup to a Kafka topic.
+//
+// The package does not depend on a Kafka client, wrap the client in use (sarama, kafka-go, franz-go ...) as a Producer.
+package kafka
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AlfredAlan/rotate"
+	"go.uber.org/multierr"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultBatchSize   = 500
+	defaultMaxLineSize = 1024 * 1024
+)
+
+var (
+	ErrTopicIsEmpty = errors.New("error: topic is empty")
+	ErrNoProducer   = errors.New("error: producer is nil")
+)
+
+type (
+	// Message is one line of a backup
+	Message struct {
+		Key   []byte
+		Value []byte
+	}
+
+	// Producer send a batch of messages to topic, it should return only when the batch is acknowledged
+	Producer interface {
+		Produce(ctx context.Context, topic string, messages []Message) error
+	}
+
+	// Shipper stream backups to Kafka, the returned url kafka://topic/name marks the file shipped
+	// in the manifest and lets WithDeleteAfterUpload remove it
+	Shipper struct {
+		topic    string
+		producer Producer
+		opt      *shipperOption
+	}
+
+	shipperOption struct {
+		batchSize   int
+		maxLineSize int
+		key         func(filename string, line []byte) []byte
+	}
+	Option func(*shipperOption)
+)
+
+var _ rotate.Uploader = (*Shipper)(nil)
+
+// NewShipper ship to topic through producer, messages are keyed by backup name by default
+// so the lines of a file stay ordered within one partition
+func NewShipper(topic string, producer Producer, options ...Option) (*Shipper, error) {
+	if len(topic) == 0 {
+		return nil, ErrTopicIsEmpty
+	}
+	if producer == nil {
+		return nil, ErrNoProducer
+	}
+	opt := &shipperOption{
+		batchSize:   defaultBatchSize,
+		maxLineSize: defaultMaxLineSize,
+		key: func(filename string, _ []byte) []byte {
+			return []byte(filepath.Base(filename))
+		},
+	}
+	for _, fn := range options {
+		fn(opt)
+	}
+	return &Shipper{topic: topic, producer: producer, opt: opt}, nil
+}
+
+// WithBatchSize send at most n messages per Produce call
+func WithBatchSize(n int) Option {
+	return func(o *shipperOption) {
+		if n <= 0 {
+			o.batchSize = defaultBatchSize
+			return
+		}
+		o.batchSize = n
+	}
+}
+
+// WithMaxLineSize fail the upload of files with lines longer than n bytes
+func WithMaxLineSize(n int) Option {
+	return func(o *shipperOption) {
+		if n <= 0 {
+			o.maxLineSize = defaultMaxLineSize
+			return
+		}
+		o.maxLineSize = n
+	}
+}
+
+// WithKeyFunc compute the message key of every line
+func WithKeyFunc(fn func(filename string, line []byte) []byte) Option {
+	return func(o *shipperOption) {
+		if fn != nil {
+			o.key = fn
+		}
+	}
+}
+
+// Upload send every line of filename, gzip compressed backups are decompressed transparently
+func (s *Shipper) Upload(ctx context.Context, filename string) (_ string, err error) {
+	fp, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		err = multierr.Append(err, fp.Close())
+	}()
+
+	var in io.Reader = fp
+	if strings.HasSuffix(filename, ".gz") {
+		var gz *gzip.Reader
+		if gz, err = gzip.NewReader(fp); err != nil {
+			return "", err
+		}
+		defer func() {
+			err = multierr.Append(err, gz.Close())
+		}()
+		in = gz
+	}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), s.opt.maxLineSize)
+	batch := make([]Message, 0, s.opt.batchSize)
+	for scanner.Scan() {
+		line := append([]byte(nil), scanner.Bytes()...)
+		batch = append(batch, Message{Key: s.opt.key(filename, line), Value: line})
+		if len(batch) < s.opt.batchSize {
+			continue
+		}
+		if err = s.producer.Produce(ctx, s.topic, batch); err != nil {
+			return "", err
+		}
+		batch = make([]Message, 0, s.opt.batchSize)
+	}
+	if err = scanner.Err(); err != nil {
+		return "", err
+	}
+	if len(batch) > 0 {
+		if err = s.producer.Produce(ctx, s.topic, batch); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("kafka://%s/%s", s.topic, filepath.Base(filename)), nil
+}
@@ -0,0 +1,71 @@
+package kafka
+
+import (
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type mockProducer struct {
+	batches [][]Message
+}
+
+func (m *mockProducer) Produce(_ context.Context, _ string, messages []Message) error {
+	m.batches = append(m.batches, messages)
+	return nil
+}
+
+func TestShipper_Upload(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name() + ".gz"
+	if err := os.Rename(tmpFile.Name(), tmpFileName); err != nil {
+		t.Fatal(err)
+	}
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	w := gzip.NewWriter(tmpFile)
+	if _, err := w.Write([]byte("a\nb\nc\n")); err != nil {
+		t.Fatal(err)
+	} else if err := w.Close(); err != nil {
+		t.Fatal(err)
+	} else if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	producer := &mockProducer{}
+	shipper, err := NewShipper("logs", producer, WithBatchSize(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, err := shipper.Upload(context.Background(), tmpFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "kafka://logs/"+filepath.Base(tmpFileName) {
+		t.Errorf("url incorrect, got:%v", url)
+	}
+	if len(producer.batches) != 2 || len(producer.batches[0]) != 2 || len(producer.batches[1]) != 1 {
+		t.Fatalf("batches incorrect, got:%v", producer.batches)
+	}
+	if string(producer.batches[1][0].Value) != "c" || string(producer.batches[1][0].Key) != filepath.Base(tmpFileName) {
+		t.Errorf("message incorrect, got:%+v", producer.batches[1][0])
+	}
+}
+
+func TestNewShipper(t *testing.T) {
+	if _, err := NewShipper("", &mockProducer{}); err != ErrTopicIsEmpty {
+		t.Errorf("empty topic incorrect, got:%v", err)
+	}
+	if _, err := NewShipper("logs", nil); err != ErrNoProducer {
+		t.Errorf("nil producer incorrect, got:%v", err)
+	}
+}
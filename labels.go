@@ -0,0 +1,35 @@
+package rotate
+
+// LabeledSink is a MetricsSink able to tell writers apart, the writer reports to the sink
+// returned by WithLabels instead, see the prometheus and statsd packages
+type LabeledSink interface {
+	MetricsSink
+	WithLabels(labels map[string]string) MetricsSink
+}
+
+// WithLabels attach labels to the writer, e.g. {"service": "api"}, so fleets with many writers per
+// process can tell them apart. They are set on events and passed to a LabeledSink.
+func WithLabels(labels map[string]string) RotateOption {
+	return func(o *rotateOption) {
+		o.labels = make(map[string]string, len(labels))
+		for k, v := range labels {
+			o.labels[k] = v
+		}
+	}
+}
+
+// Labels return a copy of the labels of the writer
+func (r *RotateWriter) Labels() map[string]string {
+	labels := make(map[string]string, len(r.opt.labels))
+	for k, v := range r.opt.labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// labelSink hand the labels to a LabeledSink, called once by the constructor
+func (r *RotateWriter) labelSink() {
+	if sink, ok := r.opt.metrics.(LabeledSink); ok && len(r.opt.labels) > 0 {
+		r.opt.metrics = sink.WithLabels(r.Labels())
+	}
+}
@@ -0,0 +1,82 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type labeledMockSink struct {
+	*mockSink
+	mu     sync.Mutex
+	labels map[string]string
+}
+
+func (l *labeledMockSink) WithLabels(labels map[string]string) MetricsSink {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.labels = labels
+	return l.mockSink
+}
+
+func TestRotateWriter_WithLabels(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "labels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	labels := map[string]string{"writer": "app"}
+	sink := &labeledMockSink{
+		mockSink: &mockSink{counters: map[string]int64{}, gauges: map[string]float64{}, timings: map[string]int{}},
+	}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithLabels(labels), WithMetrics(sink, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	// the writer keep its own copy
+	labels["writer"] = "changed"
+
+	events := make(chan Event, 4)
+	cancel := writer.Listen(func(e Event) {
+		events <- e
+	})
+	defer cancel()
+	rotated, stop := writer.Subscribe()
+	defer stop()
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case e := <-events:
+		if started, ok := e.(RotateStarted); !ok || started.Labels["writer"] != "app" {
+			t.Errorf("event incorrect, got:%+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+	select {
+	case e := <-rotated:
+		if e.Labels["writer"] != "app" {
+			t.Errorf("rotate event incorrect, got:%+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no rotate event")
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if sink.labels["writer"] != "app" {
+		t.Errorf("sink labels incorrect, got:%v", sink.labels)
+	}
+	if got := writer.Labels(); len(got) != 1 || got["writer"] != "app" {
+		t.Errorf("labels incorrect, got:%v", got)
+	}
+}
@@ -0,0 +1,77 @@
+package rotate
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// lumberjackFormat is the timestamp layout of lumberjack backups, name-2006-01-02T15-04-05.000.ext
+const lumberjackFormat = "2006-01-02T15-04-05.000"
+
+// WithLumberjackBackups let retention manage backups left by lumberjack before migration, plain or
+// compressed, whatever the delimiter and time format of the writer. They count as the oldest backups.
+func WithLumberjackBackups(enable bool) RotateOption {
+	return func(o *rotateOption) {
+		o.lumberjack = enable
+	}
+}
+
+// lumberjackTime parse the timestamp of a lumberjack backup name, false if file is not one
+func (r *RotateWriter) lumberjackTime(file string) (time.Time, bool) {
+	if !r.opt.lumberjack {
+		return time.Time{}, false
+	}
+	_, prefix, ext := r.paths()
+	date := strings.TrimSuffix(file, ".gz")
+	if !strings.HasPrefix(date, prefix+"-") || !strings.HasSuffix(date, ext) {
+		return time.Time{}, false
+	}
+	date = strings.TrimSuffix(strings.TrimPrefix(date, prefix+"-"), ext)
+	loc := time.UTC
+	if r.opt.localTime {
+		loc = time.Local
+	}
+	ts, err := time.ParseInLocation(lumberjackFormat, date, loc)
+	return ts, err == nil
+}
+
+// appendLumberjackFiles add lumberjack backups missing from files
+func (r *RotateWriter) appendLumberjackFiles(files []string) ([]string, error) {
+	_, prefix, ext := r.paths()
+	candidates, err := filepath.Glob(fmt.Sprintf("%s-*%s*", globEscape(prefix), globEscape(ext)))
+	if err != nil {
+		return []string{}, err
+	}
+	seen := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		seen[file] = struct{}{}
+	}
+	for _, file := range candidates {
+		if _, ok := seen[file]; ok {
+			continue
+		}
+		if _, ok := r.lumberjackTime(file); ok {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// sortLumberjackFirst order lumberjack backups by time before the backups of the writer
+func (r *RotateWriter) sortLumberjackFirst(files []string) {
+	sort.SliceStable(files, func(i, j int) bool {
+		ti, iok := r.lumberjackTime(files[i])
+		tj, jok := r.lumberjackTime(files[j])
+		switch {
+		case iok && jok:
+			return ti.Before(tj)
+		case iok != jok:
+			return iok
+		default:
+			return files[i] < files[j]
+		}
+	})
+}
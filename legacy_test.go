@@ -0,0 +1,66 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_lumberjackBackups(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(
+		tmpFileName,
+		WithGzip(true),
+		WithDelimiter("_"),
+		WithMaxDays(30),
+		WithLumberjackBackups(true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	outdated := mockBackupName(tmpFileName, time.Now().Add(-40*24*time.Hour).Format(lumberjackFormat)) + ".gz"
+	recent := mockBackupName(tmpFileName, time.Now().Add(-time.Hour).Format(lumberjackFormat))
+	for _, name := range []string{outdated, recent} {
+		if err := ioutil.WriteFile(name, []byte("test"), defaultFilePerm); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(name)
+	}
+
+	files, err := writer.listFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	if len(files) != 2 {
+		t.Fatalf("lumberjack backups should be listed, got:%v", files)
+	}
+
+	writer.removeOutdatedFiles()
+	if writer.err != nil {
+		t.Fatal(writer.err)
+	}
+	if _, err := os.Stat(outdated); !os.IsNotExist(err) {
+		t.Errorf("outdated lumberjack backup should be removed")
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Errorf("recent lumberjack backup should be kept: %v", err)
+	}
+}
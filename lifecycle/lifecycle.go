@@ -0,0 +1,52 @@
+// Package lifecycle close rotate writers when the process is asked to stop.
+package lifecycle
+
+import (
+	"context"
+	"go.uber.org/multierr"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// syncer is implemented by writers able to flush before closing
+type syncer interface {
+	Sync() error
+}
+
+// Notify return a context canceled on SIGINT or SIGTERM, when parent is done or when wait is
+// called, the writers are synced and closed once it is canceled. wait cancel the context if no
+// signal came, block until the writers are closed and return their errors, call it last in main
+// so no data is lost on shutdown.
+func Notify(parent context.Context, writers ...io.Closer) (ctx context.Context, wait func() error) {
+	notified, stop := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	ctx, cancel := context.WithCancel(notified)
+	var (
+		err  error
+		done = make(chan struct{})
+	)
+	go func() {
+		<-ctx.Done()
+		stop()
+		err = Close(writers...)
+		close(done)
+	}()
+	return ctx, func() error {
+		cancel()
+		<-done
+		return err
+	}
+}
+
+// Close sync then close every writer, it keep going on errors and return all of them
+func Close(writers ...io.Closer) error {
+	var err error
+	for _, w := range writers {
+		if s, ok := w.(syncer); ok {
+			err = multierr.Append(err, s.Sync())
+		}
+		err = multierr.Append(err, w.Close())
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type mockWriter struct {
+	synced, closed bool
+	err            error
+}
+
+func (m *mockWriter) Sync() error {
+	m.synced = true
+	return nil
+}
+
+func (m *mockWriter) Close() error {
+	m.closed = true
+	return m.err
+}
+
+func TestNotify(t *testing.T) {
+	first, second := &mockWriter{}, &mockWriter{err: errors.New("close failed")}
+	ctx, wait := Notify(context.Background(), first, second)
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context should be canceled on SIGTERM")
+	}
+	if err := wait(); err != second.err {
+		t.Errorf("close error incorrect, got:%v", err)
+	}
+	if !first.synced || !first.closed || !second.closed {
+		t.Errorf("writers should be synced and closed, got:%+v %+v", first, second)
+	}
+}
+
+func TestNotify_parent(t *testing.T) {
+	w := &mockWriter{}
+	parent, cancel := context.WithCancel(context.Background())
+	_, wait := Notify(parent, w)
+	cancel()
+	if err := wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !w.closed {
+		t.Error("writer should be closed when parent is done")
+	}
+}
+
+func TestNotify_noSignal(t *testing.T) {
+	w := &mockWriter{}
+	ctx, wait := Notify(context.Background(), w)
+	returned := make(chan error, 1)
+	go func() {
+		returned <- wait()
+	}()
+	select {
+	case err := <-returned:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wait should not block without a signal")
+	}
+	if ctx.Err() == nil || !w.synced || !w.closed {
+		t.Errorf("wait should cancel the context and close the writers, got:%v %+v", ctx.Err(), w)
+	}
+}
@@ -0,0 +1,107 @@
+// Package lumberjack provide a Logger with the exported fields of gopkg.in/natefinch/lumberjack.v2,
+// implemented on top of rotate.RotateWriter, so code can migrate by changing the import path.
+package lumberjack
+
+import (
+	"fmt"
+	"github.com/AlfredAlan/rotate"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	defaultMaxSize = 100
+	backupFormat   = "2006-01-02T15-04-05.000"
+)
+
+// Logger is an io.WriteCloser writing to Filename, the file is opened on the first write
+// and the fields must not be changed afterwards
+type Logger struct {
+	// Filename is the file to write logs to, default <processname>-lumberjack.log in os.TempDir()
+	Filename string `json:"filename" yaml:"filename"`
+	// MaxSize is the maximum size in megabytes before rotation, default 100
+	MaxSize int `json:"maxsize" yaml:"maxsize"`
+	// MaxAge is the maximum number of days to retain backups, 0 keep them regardless of age
+	MaxAge int `json:"maxage" yaml:"maxage"`
+	// MaxBackups is the maximum number of backups to retain, 0 retain all of them
+	MaxBackups int `json:"maxbackups" yaml:"maxbackups"`
+	// LocalTime use local time in backup names instead of UTC
+	LocalTime bool `json:"localtime" yaml:"localtime"`
+	// Compress gzip the backups
+	Compress bool `json:"compress" yaml:"compress"`
+
+	mu sync.Mutex
+	w  *rotate.RotateWriter
+}
+
+var _ io.WriteCloser = (*Logger)(nil)
+
+// Write
+func (l *Logger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.open(); err != nil {
+		return 0, err
+	}
+	return l.w.Write(p)
+}
+
+// Rotate close the current file and start a new one
+func (l *Logger) Rotate() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.w == nil {
+		return l.open()
+	}
+	_, err := l.w.Rotate()
+	return err
+}
+
+// Close close the current file, a later Write open it again
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.w == nil {
+		return nil
+	}
+	err := l.w.Close()
+	l.w = nil
+	return err
+}
+
+// open
+func (l *Logger) open() error {
+	if l.w != nil {
+		return nil
+	}
+	maxSize := l.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxSize
+	}
+	w, err := rotate.NewRotateWriter(
+		l.filename(),
+		rotate.WithMaxSize(int64(maxSize)),
+		rotate.WithMaxDays(int64(l.MaxAge)),
+		rotate.WithMaxBackups(int64(l.MaxBackups)),
+		rotate.WithLocalTime(l.LocalTime),
+		rotate.WithGzip(l.Compress),
+		rotate.WithDelimiter("-"),
+		rotate.WithTimeFormat(backupFormat),
+	)
+	if err != nil {
+		return err
+	}
+	l.w = w
+	return nil
+}
+
+// filename
+func (l *Logger) filename() string {
+	if len(l.Filename) > 0 {
+		return l.Filename
+	}
+	name := fmt.Sprintf("%s-lumberjack.log", filepath.Base(os.Args[0]))
+	return filepath.Join(os.TempDir(), name)
+}
@@ -0,0 +1,52 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "lumberjack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:   filepath.Join(dir, "foo.log"),
+		MaxSize:    1,
+		MaxBackups: 3,
+	}
+	if _, err := l.Write([]byte("first\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Write([]byte("second\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := filepath.Glob(filepath.Join(dir, "foo-*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("backups incorrect, got:%v", backups)
+	}
+	if got, err := ioutil.ReadFile(backups[0]); err != nil {
+		t.Fatal(err)
+	} else if string(got) != "first\n" {
+		t.Errorf("backup content incorrect, got:%q", got)
+	}
+	if got, err := ioutil.ReadFile(l.Filename); err != nil {
+		t.Fatal(err)
+	} else if string(got) != "second\n" {
+		t.Errorf("active content incorrect, got:%q", got)
+	}
+}
@@ -0,0 +1,74 @@
+package rotate
+
+import (
+	"time"
+)
+
+const day = 24 * time.Hour
+
+// WithMaintenanceWindow defer the processing of backups rotated outside the daily window from
+// start to end, given as offsets from midnight, e.g. 2*time.Hour and 5*time.Hour. Compression,
+// upload, recompression and retention of those backups run when the window opens, together with
+// the plain backups left by a previous run. Rotation and the directory quota are not deferred.
+// A window with start after end spans midnight.
+func WithMaintenanceWindow(start, end time.Duration) RotateOption {
+	return func(o *rotateOption) {
+		o.windowStart = start % day
+		o.windowEnd = end % day
+		o.window = true
+	}
+}
+
+// deferBackup keep b for the next window if it is closed, only called by afterRotate
+func (r *RotateWriter) deferBackup(b backup) bool {
+	if !r.opt.window || r.inWindow(r.now()) {
+		return false
+	}
+	r.deferred = append(r.deferred, b)
+	r.enforceQuota()
+	return true
+}
+
+// maintain process the deferred backups, only called by afterRotate
+func (r *RotateWriter) maintain() {
+	deferred := r.deferred
+	r.deferred = nil
+	for _, b := range deferred {
+		r.process(b)
+	}
+}
+
+// inWindow
+func (r *RotateWriter) inWindow(now time.Time) bool {
+	tod := r.timeOfDay(now)
+	if r.opt.windowStart <= r.opt.windowEnd {
+		return tod >= r.opt.windowStart && tod < r.opt.windowEnd
+	}
+	return tod >= r.opt.windowStart || tod < r.opt.windowEnd
+}
+
+// untilWindow return how long until the window opens, zero if it is open
+func (r *RotateWriter) untilWindow(now time.Time) time.Duration {
+	if r.inWindow(now) {
+		return 0
+	}
+	return r.nextWindow(now)
+}
+
+// nextWindow return how long until the next opening of the window, strictly in the future
+func (r *RotateWriter) nextWindow(now time.Time) time.Duration {
+	d := r.opt.windowStart - r.timeOfDay(now)
+	if d <= 0 {
+		d += day
+	}
+	return d
+}
+
+// timeOfDay
+func (r *RotateWriter) timeOfDay(now time.Time) time.Duration {
+	if !r.opt.localTime {
+		now = now.UTC()
+	}
+	y, m, d := now.Date()
+	return now.Sub(time.Date(y, m, d, 0, 0, 0, 0, now.Location()))
+}
@@ -0,0 +1,70 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_WithMaintenanceWindow(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "maintenance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the window opens 100ms after the writer starts
+	clock := &manualClock{now: time.Date(2024, 5, 1, 12, 59, 59, 900000000, time.UTC)}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithLocalTime(false),
+		WithMaintenanceWindow(13*time.Hour, 14*time.Hour), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backupName := writer.backupName
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(backupName); err != nil {
+		t.Errorf("compression should wait for the window, got:%v", err)
+	}
+
+	clock.Add(time.Second)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(backupName + ".gz"); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("backup should be compressed once the window opens")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRotateWriter_inWindow(t *testing.T) {
+	writer := &RotateWriter{opt: &rotateOption{windowStart: 22 * time.Hour, windowEnd: 2 * time.Hour}}
+	at := func(hour int) time.Time {
+		return time.Date(2024, 5, 1, hour, 0, 0, 0, time.UTC)
+	}
+	for hour, want := range map[int]bool{21: false, 22: true, 23: true, 0: true, 1: true, 2: false, 12: false} {
+		if got := writer.inWindow(at(hour)); got != want {
+			t.Errorf("inWindow at %d incorrect, got:%v", hour, got)
+		}
+	}
+	if d := writer.nextWindow(at(22)); d != day {
+		t.Errorf("nextWindow incorrect, got:%v", d)
+	}
+	if d := writer.untilWindow(at(20)); d != 2*time.Hour {
+		t.Errorf("untilWindow incorrect, got:%v", d)
+	}
+}
@@ -0,0 +1,36 @@
+package rotate
+
+import (
+	"bytes"
+)
+
+// WithRotateMarker rotate right after a record equal to marker is written, a trailing newline is
+// ignored on both sides. Batch jobs write the marker at the end of each job so files follow jobs.
+func WithRotateMarker(marker []byte) RotateOption {
+	return func(o *rotateOption) {
+		o.rotateMarker = bytes.TrimSuffix(marker, []byte{'\n'})
+	}
+}
+
+// RotateAfterNext rotate right after the next record is written, so that record ends the active file
+func (r *RotateWriter) RotateAfterNext() {
+	r.mu.Lock()
+	r.rotateNext = true
+	r.mu.Unlock()
+	if r.mirror != nil {
+		r.mirror.RotateAfterNext()
+	}
+}
+
+// rotateAfter rotate once record is written if it ends the file, the lock must be held.
+// The record is already written so a failed rotation is reported to the next Write.
+func (r *RotateWriter) rotateAfter(record []byte) {
+	marker := len(r.opt.rotateMarker) > 0 && bytes.Equal(bytes.TrimSuffix(record, []byte{'\n'}), r.opt.rotateMarker)
+	if !marker && !r.rotateNext {
+		return
+	}
+	r.rotateNext = false
+	if _, err := r.rotate(); err != nil {
+		r.setErr(err)
+	}
+}
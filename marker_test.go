@@ -0,0 +1,85 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_WithRotateMarker(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "marker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "app.log")
+	writer, err := NewRotateWriter(filename, WithRotateMarker([]byte("--- job done ---\n")),
+		WithClock(&stepClock{now: time.Now()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for _, line := range []string{"job 1\n", "--- job done ---\n", "job 2\n", "--- job done ---", "job 3\n"} {
+		if _, err := writer.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("backups incorrect, got:%+v", backups)
+	}
+	for i, want := range []string{"job 1\n--- job done ---\n", "job 2\n--- job done ---"} {
+		if data, err := ioutil.ReadFile(backups[i].Name); err != nil || string(data) != want {
+			t.Errorf("backup %d incorrect, got:%q %v", i, data, err)
+		}
+	}
+	if data, err := ioutil.ReadFile(filename); err != nil || string(data) != "job 3\n" {
+		t.Errorf("active file incorrect, got:%q %v", data, err)
+	}
+}
+
+func TestRotateWriter_RotateAfterNext(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "marker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "app.log")
+	writer, err := NewRotateWriter(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := writer.Write([]byte("first\n")); err != nil {
+		t.Fatal(err)
+	}
+	writer.RotateAfterNext()
+	if _, err := writer.Write([]byte("last\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte("next\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("backups incorrect, got:%+v", backups)
+	}
+	if data, err := ioutil.ReadFile(backups[0].Name); err != nil || string(data) != "first\nlast\n" {
+		t.Errorf("backup incorrect, got:%q %v", data, err)
+	}
+	if data, err := ioutil.ReadFile(filename); err != nil || string(data) != "next\n" {
+		t.Errorf("active file incorrect, got:%q %v", data, err)
+	}
+}
@@ -0,0 +1,83 @@
+package rotate
+
+import (
+	"time"
+)
+
+const defaultMetricsInterval = 10 * time.Second
+
+// MetricsSink receive the writer metrics, see the prometheus and statsd packages for adapters.
+// Counters are reported as deltas, names are snake case without prefix, e.g. bytes_written.
+type MetricsSink interface {
+	Counter(name string, delta int64)
+	Gauge(name string, value float64)
+	Timing(name string, d time.Duration)
+}
+
+// WithMetrics report the Stats counters and the queue depth to sink every interval, default
+// to 10s, and before Close returns. The duration of every rotation and compression is reported as it happens.
+func WithMetrics(sink MetricsSink, interval time.Duration) RotateOption {
+	return func(o *rotateOption) {
+		o.metrics = sink
+		o.metricsInterval = interval
+		if interval <= 0 {
+			o.metricsInterval = defaultMetricsInterval
+		}
+	}
+}
+
+// reportMetrics
+func (r *RotateWriter) reportMetrics() {
+	ticker := time.NewTicker(r.opt.metricsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.flushMetrics()
+		case <-r.postDone:
+			return
+		}
+	}
+}
+
+// flushMetrics report the counters changed since the last report
+func (r *RotateWriter) flushMetrics() {
+	if r.opt.metrics == nil {
+		return
+	}
+	r.metricsMu.Lock()
+	defer r.metricsMu.Unlock()
+	r.reported = r.reportStats(r.reported)
+}
+
+// reportStats send the counters changed since last and return the current stats
+func (r *RotateWriter) reportStats(last Stats) Stats {
+	stats := r.Stats()
+	for _, c := range []struct {
+		name       string
+		now, since int64
+	}{
+		{"bytes_written", stats.BytesWritten, last.BytesWritten},
+		{"writes", stats.Writes, last.Writes},
+		{"rotations", stats.Rotations, last.Rotations},
+		{"compressions", stats.Compressions, last.Compressions},
+		{"deleted_backups", stats.DeletedBackups, last.DeletedBackups},
+		{"dropped", stats.Dropped, last.Dropped},
+		{"suppressed", stats.Suppressed, last.Suppressed},
+		{"syncs", stats.Syncs, last.Syncs},
+		{"errors", stats.Errors, last.Errors},
+	} {
+		if c.now != c.since {
+			r.opt.metrics.Counter(c.name, c.now-c.since)
+		}
+	}
+	r.opt.metrics.Gauge("queue_depth", float64(stats.QueueDepth))
+	return stats
+}
+
+// timing report d since start if a sink is set
+func (r *RotateWriter) timing(name string, start time.Time) {
+	if r.opt.metrics != nil {
+		r.opt.metrics.Timing(name, time.Since(start))
+	}
+}
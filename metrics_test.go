@@ -0,0 +1,97 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockSink struct {
+	mu       sync.Mutex
+	counters map[string]int64
+	gauges   map[string]float64
+	timings  map[string]int
+}
+
+func (m *mockSink) Counter(name string, delta int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name] += delta
+}
+
+func (m *mockSink) Gauge(name string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gauges[name] = value
+}
+
+func (m *mockSink) Timing(name string, _ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timings[name]++
+}
+
+func TestRotateWriter_WithMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sink := &mockSink{counters: map[string]int64{}, gauges: map[string]float64{}, timings: map[string]int{}}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithMetrics(sink, 10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for i := 0; i < 2; i++ {
+		if _, err := writer.Write([]byte("test\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if sink.counters["writes"] != 2 || sink.counters["bytes_written"] != 10 || sink.counters["rotations"] != 1 {
+		t.Errorf("counters incorrect, got:%v", sink.counters)
+	}
+	if _, ok := sink.gauges["queue_depth"]; !ok {
+		t.Errorf("gauges incorrect, got:%v", sink.gauges)
+	}
+	if sink.timings["rotate"] != 1 {
+		t.Errorf("timings incorrect, got:%v", sink.timings)
+	}
+}
+
+func TestRotateWriter_WithMetrics_Close(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sink := &mockSink{counters: map[string]int64{}, gauges: map[string]float64{}, timings: map[string]int{}}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithMetrics(sink, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if sink.counters["writes"] != 1 || sink.counters["bytes_written"] != 5 {
+		t.Errorf("counters incorrect, got:%v", sink.counters)
+	}
+}
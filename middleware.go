@@ -0,0 +1,73 @@
+package rotate
+
+import "time"
+
+type (
+	// WriteFunc write one record
+	WriteFunc func(data []byte) error
+
+	// Middleware wrap the next WriteFunc, e.g. to transform or drop records. Middlewares run
+	// while the writer lock is held, so they may keep state without synchronization.
+	Middleware func(next WriteFunc) WriteFunc
+)
+
+// WithMiddleware append mws to the write chain, the first middleware added see records first.
+// WithRedactor and WithTimestampPrefix add their middleware at the position they are given too,
+// WithDedup and WithSampling always run before the chain.
+func WithMiddleware(mws ...Middleware) RotateOption {
+	return func(o *rotateOption) {
+		for _, mw := range mws {
+			mw := mw
+			o.middlewares = append(o.middlewares, func(*rotateOption) Middleware {
+				return mw
+			})
+		}
+	}
+}
+
+// Redact apply fn to every record, fn must not modify its argument in place
+func Redact(fn func([]byte) []byte) Middleware {
+	return func(next WriteFunc) WriteFunc {
+		return func(data []byte) error {
+			return next(fn(data))
+		}
+	}
+}
+
+// Timestamp prefix every record with the time formatted by layout and a space,
+// the time never goes backwards even if the wall clock does
+func Timestamp(layout string, utc bool) Middleware {
+	return timestamp(layout, utc, time.Now)
+}
+
+// timestamp is Timestamp reading the time from clock
+func timestamp(layout string, utc bool, clock func() time.Time) Middleware {
+	return func(next WriteFunc) WriteFunc {
+		var last time.Time
+		return func(data []byte) error {
+			now := clock()
+			if utc {
+				now = now.UTC()
+			}
+			// keep timestamps ordered across wall clock steps
+			if now.Before(last) {
+				now = last
+			}
+			last = now
+
+			buf := make([]byte, 0, len(layout)+len(data)+1)
+			buf = now.AppendFormat(buf, layout)
+			buf = append(buf, ' ')
+			return next(append(buf, data...))
+		}
+	}
+}
+
+// buildChain
+func (r *RotateWriter) buildChain() WriteFunc {
+	chain := WriteFunc(r.writeRecord)
+	for i := len(r.opt.middlewares) - 1; i >= 0; i-- {
+		chain = r.opt.middlewares[i](r.opt)(chain)
+	}
+	return chain
+}
@@ -0,0 +1,68 @@
+package rotate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestRotateWriter_WithMiddleware(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	upper := func(next WriteFunc) WriteFunc {
+		return func(data []byte) error {
+			return next(bytes.ToUpper(data))
+		}
+	}
+	dropEmpty := func(next WriteFunc) WriteFunc {
+		return func(data []byte) error {
+			if len(bytes.TrimSpace(data)) == 0 {
+				return nil
+			}
+			return next(data)
+		}
+	}
+	writer, err := NewRotateWriter(
+		tmpFileName,
+		WithMiddleware(dropEmpty),
+		WithRedactor(func(data []byte) []byte {
+			return bytes.ReplaceAll(data, []byte("secret"), []byte("***"))
+		}),
+		WithMiddleware(upper),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range []string{"a secret\n", "\n", "b\n"} {
+		if n, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		} else if n != len(data) {
+			t.Errorf("written count incorrect, got:%v", n)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(tmpFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// redaction runs before upper casing, so SECRET would not have been masked
+	if string(got) != "A ***\nB\n" {
+		t.Errorf("content incorrect, got:%q", got)
+	}
+}
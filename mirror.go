@@ -0,0 +1,86 @@
+package rotate
+
+import (
+	"context"
+	"go.uber.org/multierr"
+)
+
+// WithMirror write every record to filename too, e.g. on another volume. The mirror has its own
+// rotation and retention with the same options, except that it does not upload, listen on the
+// control socket, handle signals, keep a symlink, report metrics or traces, or fail over, and use
+// default index and manifest names.
+// A write succeed if either side succeed, the failure of the other side is reported by Close.
+// Rotate, Sync and Close apply to both sides. A mirror failing to open, write, rotate or sync
+// never fails the primary, it is counted in Stats.MirrorErrors, sent as MirrorFailed and
+// reported by Close.
+func WithMirror(filename string) RotateOption {
+	return func(o *rotateOption) {
+		o.mirror = filename
+	}
+}
+
+// mirrorOnly drop the options that must not be shared with the primary writer
+func mirrorOnly(o *rotateOption) {
+	o.mirror = ""
+	o.uploader = nil
+	o.deleteAfterUpload = false
+	o.controlSocket = ""
+	o.syncSignals = nil
+	o.symlink = ""
+	o.indexName = ""
+	o.manifestName = ""
+	o.registry = false
+	// the primary reports for both, a shared failover directory would mix their files
+	o.metrics = nil
+	o.tracer = nil
+	o.failoverDir = ""
+}
+
+// openMirror open the mirror, the writer goes on without it if it fails
+func (r *RotateWriter) openMirror(options []RotateOption) {
+	mirrorOptions := make([]RotateOption, 0, len(options)+1)
+	mirrorOptions = append(mirrorOptions, options...)
+	mirrorOptions = append(mirrorOptions, mirrorOnly)
+	mirror, err := NewRotateWriter(r.opt.mirror, mirrorOptions...)
+	if err != nil {
+		r.mu.Lock()
+		r.mirrorFailedLocked(err)
+		r.mu.Unlock()
+		return
+	}
+	r.mirror = mirror
+}
+
+// writeMirror write data to the mirror and combine the results, n and err are those of the primary
+func (r *RotateWriter) writeMirror(ctx context.Context, data []byte, n int, err error) (int, error) {
+	mn, mirrorErr := r.mirror.WriteContext(ctx, data)
+	switch {
+	case err == nil && mirrorErr == nil:
+		return n, nil
+	case err != nil && mirrorErr != nil:
+		return 0, multierr.Append(err, mirrorErr)
+	case err != nil:
+		r.keepSideErr(err)
+		return mn, nil
+	default:
+		r.mu.Lock()
+		r.mirrorFailedLocked(mirrorErr)
+		r.mu.Unlock()
+		return n, nil
+	}
+}
+
+// mirrorFailedLocked record a failure of the mirror for Stats, subscribers and Close, the lock
+// must be held
+func (r *RotateWriter) mirrorFailedLocked(err error) {
+	r.stats.mirrorErrors.Inc()
+	r.stats.lastErr.Store(err)
+	r.keepErr(err)
+	r.emit(MirrorFailed{Err: err})
+}
+
+// keepSideErr record the failure of one side for Close
+func (r *RotateWriter) keepSideErr(err error) {
+	r.stats.lastErr.Store(err)
+	r.keepErr(err)
+}
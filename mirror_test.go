@@ -0,0 +1,181 @@
+package rotate
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_WithMirror(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	primary, mirror := filepath.Join(dir, "a", "app.log"), filepath.Join(dir, "b", "app.log")
+	writer, err := NewRotateWriter(primary, WithMirror(mirror))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := writer.Write([]byte("both\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte("one\n")); err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []*RotateWriter{writer, writer.mirror} {
+		if backups, err := w.Backups(); err != nil || len(backups) != 1 {
+			t.Errorf("%s backups incorrect, got:%+v %v", w.filename, backups, err)
+		}
+	}
+
+	// the primary keep working without its mirror
+	if err := writer.mirror.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := writer.Write([]byte("primary\n")); err != nil || n != 8 {
+		t.Errorf("write without mirror incorrect, got:%d %v", n, err)
+	}
+	if data, err := ioutil.ReadFile(primary); err != nil || string(data) != "one\nprimary\n" {
+		t.Errorf("primary incorrect, got:%q %v", data, err)
+	}
+	if data, err := ioutil.ReadFile(mirror); err != nil || string(data) != "one\n" {
+		t.Errorf("mirror incorrect, got:%q %v", data, err)
+	}
+	if err := writer.Close(); !errors.Is(err, ErrLogFileClosed) {
+		t.Errorf("close should report the mirror failure, got:%v", err)
+	}
+}
+
+func TestRotateWriter_WithMirror_failure(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the mirror directory cannot be created below a file
+	blocker := filepath.Join(dir, "b")
+	if err := ioutil.WriteFile(blocker, nil, defaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	writer, err := NewRotateWriter(filepath.Join(dir, "a", "app.log"), WithMirror(filepath.Join(blocker, "app.log")))
+	if err != nil {
+		t.Fatalf("a mirror failing to open should not fail the writer, got:%v", err)
+	}
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if stats := writer.Stats(); stats.MirrorErrors != 1 || stats.LastError == nil {
+		t.Errorf("mirror failure should be counted, got:%+v", stats)
+	}
+	if err := writer.Close(); err == nil {
+		t.Error("close should report the mirror failure")
+	}
+}
+
+func TestRotateWriter_WithMirror_rotateFailure(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "a", "app.log"), WithMirror(filepath.Join(dir, "b", "app.log")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	failures := make(chan MirrorFailed, 1)
+	stop := writer.Listen(func(e Event) {
+		if e, ok := e.(MirrorFailed); ok {
+			failures <- e
+		}
+	})
+	defer stop()
+	if err := writer.mirror.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Errorf("a mirror failing to rotate should not fail Rotate, got:%v", err)
+	}
+	if writer.Stats().MirrorErrors != 1 {
+		t.Errorf("mirror failure should be counted, got:%+v", writer.Stats())
+	}
+	select {
+	case e := <-failures:
+		if !errors.Is(e.Err, ErrLogFileClosed) {
+			t.Errorf("mirror failure incorrect, got:%v", e.Err)
+		}
+	case <-time.After(time.Second):
+		t.Error("mirror failure should be sent")
+	}
+}
+
+func TestRotateWriter_WithMirror_deleteAfterUpload(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	primary, mirror := filepath.Join(dir, "a", "app.log"), filepath.Join(dir, "b", "app.log")
+	writer, err := NewRotateWriter(primary, WithMirror(mirror), WithUploader(&mockUploader{}),
+		WithDeleteAfterUpload(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if writer.mirror == nil || writer.Stats().MirrorErrors != 0 {
+		t.Fatalf("mirror should open, got:%v", writer.Stats().LastError)
+	}
+	if _, err := writer.Write([]byte("both\n")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ioutil.ReadFile(mirror); err != nil || string(data) != "both\n" {
+		t.Errorf("mirror incorrect, got:%q %v", data, err)
+	}
+}
+
+func TestRotateWriter_WithMirror_metrics(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sink := &mockSink{counters: map[string]int64{}, gauges: map[string]float64{}, timings: map[string]int{}}
+	primary, mirror := filepath.Join(dir, "a", "app.log"), filepath.Join(dir, "b", "app.log")
+	writer, err := NewRotateWriter(primary, WithMirror(mirror), WithMetrics(sink, time.Hour),
+		WithFailoverDir(filepath.Join(dir, "failover"), time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if writer.mirror.opt.metrics != nil || writer.mirror.opt.tracer != nil || writer.mirror.opt.failoverDir != "" {
+		t.Errorf("mirror should not share metrics, tracer or failover, got:%v %v %q",
+			writer.mirror.opt.metrics, writer.mirror.opt.tracer, writer.mirror.opt.failoverDir)
+	}
+	if _, err := writer.Write([]byte("write\n")); err != nil {
+		t.Fatal(err)
+	}
+	// the final report of Close
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if sink.counters["writes"] != 1 || sink.counters["bytes_written"] != 6 {
+		t.Errorf("counters incorrect, got:%v", sink.counters)
+	}
+}
@@ -0,0 +1,39 @@
+package rotate
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// WithExtension set the extension kept at the end of backup names, e.g. ".2024.log" for app.2024.log
+// or "" for no extension, by default it is the part after the last dot of the file name
+func WithExtension(ext string) RotateOption {
+	return func(o *rotateOption) {
+		o.ext = &ext
+	}
+}
+
+// splitName split filename into the backup prefix and extension, a dot file like .env has no extension
+// and ext is only used if filename ends with it
+func splitName(filename string, ext *string) (string, string) {
+	if ext != nil && strings.HasSuffix(filename, *ext) && len(*ext) < len(filepath.Base(filename)) {
+		return filename[:len(filename)-len(*ext)], *ext
+	}
+	e := filepath.Ext(filename)
+	if e == filepath.Base(filename) {
+		// dot file, the whole name is the base
+		e = ""
+	}
+	return filename[:len(filename)-len(e)], e
+}
+
+// excludeCompressed drop the compressed files of files and the compressions in progress
+func (r *RotateWriter) excludeCompressed(files []string) []string {
+	plain := files[:0]
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".gz") && !strings.HasSuffix(file, ".gz.tmp") && !r.recompressed(file) {
+			plain = append(plain, file)
+		}
+	}
+	return plain
+}
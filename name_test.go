@@ -0,0 +1,63 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitName(t *testing.T) {
+	multi := ".2024.log"
+	none := ""
+	tests := []struct {
+		filename string
+		ext      *string
+		prefix   string
+		wantExt  string
+	}{
+		{filename: "/var/log/app.log", prefix: "/var/log/app", wantExt: ".log"},
+		{filename: "/var/log/app", prefix: "/var/log/app", wantExt: ""},
+		{filename: "/var/log/app.2024.log", prefix: "/var/log/app.2024", wantExt: ".log"},
+		{filename: "/var/log/app.2024.log", ext: &multi, prefix: "/var/log/app", wantExt: ".2024.log"},
+		{filename: "/var/log/app.log", ext: &none, prefix: "/var/log/app.log", wantExt: ""},
+		{filename: "/var/log/.hidden", prefix: "/var/log/.hidden", wantExt: ""},
+		{filename: "/var/log/app.log", ext: &multi, prefix: "/var/log/app", wantExt: ".log"},
+	}
+	for _, tt := range tests {
+		prefix, ext := splitName(tt.filename, tt.ext)
+		if prefix != tt.prefix || ext != tt.wantExt {
+			t.Errorf("split %s incorrect, got:%v %v, want:%v %v", tt.filename, prefix, ext, tt.prefix, tt.wantExt)
+		}
+	}
+}
+
+func TestRotateWriter_extensionless(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backupName := writer.backupName
+	if _, err := writer.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	// a compressed backup left by an earlier run with gzip enabled
+	if err := ioutil.WriteFile(filepath.Join(dir, "app-old.gz"), nil, defaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := writer.listFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0] != backupName {
+		t.Errorf("backups incorrect, got:%v, want:%v", files, backupName)
+	}
+}
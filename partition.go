@@ -0,0 +1,166 @@
+package rotate
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tokens usable in WithTimeFormat next to the Go layout, a "/" in the format puts backups
+// in sub directories, e.g. "{isoyear}/W{isoweek}/2006-01-02T15"
+const (
+	TokenISOYear = "{isoyear}" // 4 digits ISO 8601 week-numbering year
+	TokenISOWeek = "{isoweek}" // 2 digits ISO 8601 week number
+	TokenQuarter = "{quarter}" // 1 digit quarter of the year
+)
+
+var timeTokens = regexp.MustCompile(`\{isoyear\}|\{isoweek\}|\{quarter\}`)
+
+// formatTime format t with a Go layout that may contain date tokens
+func formatTime(t time.Time, layout string) string {
+	if !timeTokens.MatchString(layout) {
+		return t.Format(layout)
+	}
+	var buf strings.Builder
+	last := 0
+	for _, loc := range timeTokens.FindAllStringIndex(layout, -1) {
+		buf.WriteString(t.Format(layout[last:loc[0]]))
+		year, week := t.ISOWeek()
+		switch layout[loc[0]:loc[1]] {
+		case TokenISOYear:
+			_, _ = fmt.Fprintf(&buf, "%04d", year)
+		case TokenISOWeek:
+			_, _ = fmt.Fprintf(&buf, "%02d", week)
+		case TokenQuarter:
+			_, _ = fmt.Fprintf(&buf, "%d", (int(t.Month())+2)/3)
+		}
+		last = loc[1]
+	}
+	buf.WriteString(t.Format(layout[last:]))
+	return buf.String()
+}
+
+// parseTime parse value formatted by formatTime, ISO week and quarter resolve to their first day
+// only when the Go layout has no finer day or month
+func parseTime(layout, value string, loc *time.Location) (time.Time, error) {
+	if !timeTokens.MatchString(layout) {
+		return time.ParseInLocation(layout, value, loc)
+	}
+	// match the tokens and the fixed width Go layout parts by their width, the others lazily
+	var (
+		expr    strings.Builder
+		layouts []string
+		tokens  []string
+		last    int
+	)
+	expr.WriteString("^")
+	for _, idx := range timeTokens.FindAllStringIndex(layout, -1) {
+		if idx[0] > last {
+			expr.WriteString(layoutExpr(layout[last:idx[0]]))
+			layouts = append(layouts, layout[last:idx[0]])
+		}
+		token := layout[idx[0]:idx[1]]
+		switch token {
+		case TokenISOYear:
+			expr.WriteString(`(\d{4})`)
+		case TokenISOWeek:
+			expr.WriteString(`(\d{2})`)
+		case TokenQuarter:
+			expr.WriteString(`(\d)`)
+		}
+		tokens = append(tokens, token)
+		layouts = append(layouts, token)
+		last = idx[1]
+	}
+	if last < len(layout) {
+		expr.WriteString(layoutExpr(layout[last:]))
+		layouts = append(layouts, layout[last:])
+	}
+	expr.WriteString("$")
+	m := regexp.MustCompile(expr.String()).FindStringSubmatch(value)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("error: %q does not match time format %q", value, layout)
+	}
+
+	var goLayout, goValue []string
+	values := make(map[string]int, len(tokens))
+	for i, part := range layouts {
+		if !timeTokens.MatchString(part) {
+			goLayout = append(goLayout, part)
+			goValue = append(goValue, m[i+1])
+			continue
+		}
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			return time.Time{}, err
+		}
+		values[part] = n
+	}
+	goFormat := strings.Join(goLayout, "\x00")
+	t, err := time.ParseInLocation(goFormat, strings.Join(goValue, "\x00"), loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// the same weekday on every sample, so a weekday in the layout is not taken for a day
+	base := time.Date(2001, time.January, 2, 0, 0, 0, 0, time.UTC)
+	hasDay := layoutHas(goFormat, base, time.Date(2001, time.January, 9, 0, 0, 0, 0, time.UTC))
+	hasMonth := layoutHas(goFormat, base, time.Date(2001, time.October, 2, 0, 0, 0, 0, time.UTC))
+	hasYear := layoutHas(goFormat, base, time.Date(2007, time.January, 2, 0, 0, 0, 0, time.UTC))
+	year := t.Year()
+	if y, ok := values[TokenISOYear]; ok && !hasYear {
+		year = y
+	}
+	if hasDay {
+		return t.AddDate(year-t.Year(), 0, 0), nil
+	}
+	if week, ok := values[TokenISOWeek]; ok {
+		// january 4th is always in week 1
+		jan4 := time.Date(year, time.January, 4, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+		monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+		return monday.AddDate(0, 0, 7*(week-1)), nil
+	}
+	if q, ok := values[TokenQuarter]; ok && !hasMonth {
+		return time.Date(year, time.Month(3*(q-1)+1), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc), nil
+	}
+	return t.AddDate(year-t.Year(), 0, 0), nil
+}
+
+// layoutHas report whether the Go layout tells a from b
+func layoutHas(layout string, a, b time.Time) bool {
+	return a.Format(layout) != b.Format(layout)
+}
+
+// layoutExpr return the regexp group matching values of a Go layout
+func layoutExpr(layout string) string {
+	samples := []time.Time{
+		time.Date(2001, time.May, 2, 3, 4, 5, 0, time.UTC),
+		time.Date(2017, time.September, 25, 15, 45, 55, 123456789, time.FixedZone("", -9000)),
+	}
+	width := len(samples[0].Format(layout))
+	for _, t := range samples[1:] {
+		if len(t.Format(layout)) != width {
+			return "(.+?)"
+		}
+	}
+	return fmt.Sprintf("(.{%d})", width)
+}
+
+// dateGlob return the glob matching a formatted time, one * per path element
+func dateGlob(layout string) string {
+	return strings.Repeat("*"+string(filepath.Separator), strings.Count(layout, "/")) + "*"
+}
+
+// relName return name relative to the directory of the log file with "/" separators, so backups
+// in the sub directories of a "/" time format keep their date, the base name outside of it
+func (r *RotateWriter) relName(name string) string {
+	filename, _, _ := r.paths()
+	rel, err := filepath.Rel(filepath.Dir(filename), name)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Base(name)
+	}
+	return filepath.ToSlash(rel)
+}
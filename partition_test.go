@@ -0,0 +1,170 @@
+package rotate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFormatTime_tokens(t *testing.T) {
+	ts := time.Date(2021, time.January, 3, 10, 30, 0, 0, time.UTC) // sunday of 2020-W53
+	tests := []struct {
+		layout string
+		want   string
+		parsed time.Time
+	}{
+		{"{isoyear}-W{isoweek}", "2020-W53", time.Date(2020, time.December, 28, 0, 0, 0, 0, time.UTC)},
+		{"2006-Q{quarter}T15", "2021-Q1T10", time.Date(2021, time.January, 1, 10, 0, 0, 0, time.UTC)},
+		{"2006-01-02", "2021-01-03", time.Date(2021, time.January, 3, 0, 0, 0, 0, time.UTC)},
+		// the day of the Go layout wins over the start of the week or quarter
+		{"{isoyear}/W{isoweek}/2006-01-02T15", "2020/W53/2021-01-03T10", time.Date(2021, time.January, 3, 10, 0, 0, 0, time.UTC)},
+		{"2006/Q{quarter}/01-02", "2021/Q1/01-03", time.Date(2021, time.January, 3, 0, 0, 0, 0, time.UTC)},
+		{"2006/Q{quarter}/01", "2021/Q1/01", time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got := formatTime(ts, tt.layout)
+		if got != tt.want {
+			t.Errorf("format %s incorrect, want:%s, got:%s", tt.layout, tt.want, got)
+		}
+		parsed, err := parseTime(tt.layout, got, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !parsed.Equal(tt.parsed) {
+			t.Errorf("parse %s incorrect, want:%v, got:%v", got, tt.parsed, parsed)
+		}
+	}
+	if _, err := parseTime("{isoyear}-W{isoweek}", "2020-53", time.UTC); err == nil {
+		t.Error("mismatched value should fail")
+	}
+}
+
+func TestParseTime_documentedLayout(t *testing.T) {
+	layout := "{isoyear}/W{isoweek}/2006-01-02T15"
+	for ts := time.Date(2026, time.October, 12, 7, 0, 0, 0, time.UTC); ts.Day() < 19; ts = ts.AddDate(0, 0, 1) {
+		parsed, err := parseTime(layout, formatTime(ts, layout), time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !parsed.Equal(ts) {
+			t.Errorf("round trip of %v incorrect, got:%v", ts, parsed)
+		}
+	}
+}
+
+func TestRotateWriter_partitionDirectories(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "partition")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithTimeFormat("{isoyear}/W{isoweek}/2006-01-02T15-04-05"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backupName := writer.backupName
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	year, week := time.Now().ISOWeek()
+	if filepath.Base(filepath.Dir(backupName)) != formatTime(time.Now(), "W{isoweek}") {
+		t.Errorf("backup should be in the week directory %d-%d, got:%v", year, week, backupName)
+	}
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 || backups[0].Name != backupName || backups[0].Timestamp.IsZero() {
+		t.Errorf("backups incorrect, got:%+v", backups)
+	}
+}
+
+func TestRotateWriter_partitionDirectories_sameBase(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "partition")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// both backups are named 12.log, one directory per day, after the time the file was opened
+	clock := &stepClock{now: time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithTimeFormat("2006-01-02/15"),
+		WithLocalTime(false), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for _, day := range []int{1, 2} {
+		if _, err := writer.Write([]byte(fmt.Sprintf("day %d\n", day))); err != nil {
+			t.Fatal(err)
+		}
+		clock.mu.Lock()
+		clock.now = time.Date(2024, time.January, day+1, 12, 0, 0, 0, time.UTC)
+		clock.mu.Unlock()
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := writer.Export(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+	want := []string{"app-2024-01-01/12.log", "app-2024-01-02/12.log", "app.log"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("archive entries incorrect, got:%v", names)
+	}
+
+	dst, err := ioutil.TempDir(os.TempDir(), "restore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+	restored, err := writer.RestoreAll(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, day := range []int{1, 2} {
+		if i >= len(restored) {
+			t.Fatalf("restored incorrect, got:%v", restored)
+		}
+		got, err := ioutil.ReadFile(restored[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != fmt.Sprintf("day %d\n", day) {
+			t.Errorf("%s content incorrect, got:%q", restored[i], got)
+		}
+	}
+}
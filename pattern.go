@@ -0,0 +1,94 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithBackupPattern identify backups with a glob pattern like /var/log/app.log.* instead of the
+// prefix-*.ext layout. Files whose names carry no parsable timestamp are aged by modification time.
+func WithBackupPattern(glob string) RotateOption {
+	return func(o *rotateOption) {
+		o.backupGlob = glob
+	}
+}
+
+// WithBackupRegexp identify backups with a regexp matched against the full path of every file
+// in the log directory, see WithBackupPattern
+func WithBackupRegexp(re *regexp.Regexp) RotateOption {
+	return func(o *rotateOption) {
+		o.backupRegexp = re
+	}
+}
+
+// customBackupPattern
+func (r *RotateWriter) customBackupPattern() bool {
+	return r.opt.backupRegexp != nil || len(r.opt.backupGlob) > 0
+}
+
+// matchBackups return the files matching the custom pattern, except the active file and the
+// files the writer keeps next to it
+func (r *RotateWriter) matchBackups() ([]string, error) {
+	filename, _, _ := r.paths()
+	var files []string
+	if r.opt.backupRegexp != nil {
+		dir := filepath.Dir(filename)
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return []string{}, err
+		}
+		for _, entry := range entries {
+			file := filepath.Join(dir, entry.Name())
+			if !entry.IsDir() && r.opt.backupRegexp.MatchString(file) {
+				files = append(files, file)
+			}
+		}
+	} else {
+		matches, err := filepath.Glob(r.opt.backupGlob)
+		if err != nil {
+			return []string{}, err
+		}
+		files = matches
+	}
+
+	backups := make([]string, 0, len(files))
+	for _, file := range files {
+		if !notBackup(filepath.Clean(filename), filepath.Clean(file)) {
+			backups = append(backups, file)
+		}
+	}
+	return backups, nil
+}
+
+// notBackup report whether file is the active file, one of its crash files, the active file
+// while it rotates or a temporary file, which a pattern like app.log.* matches too
+func notBackup(filename, file string) bool {
+	base := filepath.Base(file)
+	return file == filename || file == filename+rotatingSuffix || strings.HasPrefix(file, filename+crashInfix) ||
+		strings.HasSuffix(base, ".tmp") || (strings.HasPrefix(base, ".") && strings.HasSuffix(base, ".compact"))
+}
+
+// modTime
+func modTime(file string) (time.Time, bool) {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return fi.ModTime(), true
+}
+
+// sortByModTime sort files from the oldest to the newest
+func sortByModTime(files []string) {
+	times := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		times[file], _ = modTime(file)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return times[files[i]].Before(times[files[j]])
+	})
+}
@@ -0,0 +1,123 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_WithBackupPattern(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "pattern")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+
+	for _, option := range []RotateOption{
+		WithBackupPattern(filepath.Join(dir, "app.log.*")),
+		WithBackupRegexp(regexp.MustCompile(`app\.log\.\d+$`)),
+	} {
+		writer, err := NewRotateWriter(filename, WithMaxBackups(1), WithMaxDays(1), option)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// logrotate style names from before the writer
+		names := []string{filepath.Join(dir, "app.log.3"), filepath.Join(dir, "app.log.2"), filepath.Join(dir, "app.log.1")}
+		for i, name := range names {
+			if err := ioutil.WriteFile(name, []byte("test"), defaultFilePerm); err != nil {
+				t.Fatal(err)
+			}
+			mtime := time.Now().Add(-time.Duration(len(names)-i) * 12 * time.Hour)
+			if err := os.Chtimes(name, mtime, mtime); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		backups, err := writer.Backups()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(backups) != 3 || backups[0].Name != names[0] {
+			t.Fatalf("backups incorrect, got:%v", backups)
+		}
+
+		writer.removeOutdatedFiles()
+		writer.removeOverMaxFiles()
+		if writer.err != nil {
+			t.Fatal(writer.err)
+		}
+		files, err := writer.listFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 1 || files[0] != names[2] {
+			t.Errorf("retention incorrect, got:%v", files)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range names {
+			_ = os.Remove(name)
+		}
+	}
+}
+
+func TestRotateWriter_WithBackupPattern_crash(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "pattern")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+
+	writer, err := NewRotateWriter(filename, WithMaxBackups(1), WithBackupPattern(filepath.Join(dir, "app.log.*")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	crash, err := writer.CrashDump("panic: test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	others := []string{crash, filename + rotatingSuffix, filepath.Join(dir, "app.log.2.gz.tmp")}
+	for _, name := range others[1:] {
+		if err := ioutil.WriteFile(name, []byte("test"), defaultFilePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names := []string{filepath.Join(dir, "app.log.2"), filepath.Join(dir, "app.log.1")}
+	for i, name := range names {
+		if err := ioutil.WriteFile(name, []byte("test"), defaultFilePerm); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().Add(-time.Duration(len(names)-i) * time.Hour)
+		if err := os.Chtimes(name, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := writer.listFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	if !reflect.DeepEqual(files, []string{names[1], names[0]}) {
+		t.Fatalf("backups incorrect, got:%v", files)
+	}
+	writer.removeOverMaxFiles()
+	for _, name := range append(others, names[1]) {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("%s should be kept, got:%v", name, err)
+		}
+	}
+	if _, err := os.Stat(names[0]); !os.IsNotExist(err) {
+		t.Errorf("%s should be removed, got:%v", names[0], err)
+	}
+}
@@ -0,0 +1,32 @@
+package rotate
+
+// IOPriority is the I/O scheduling class of the background worker
+type IOPriority int
+
+const (
+	IOPriorityNormal IOPriority = iota // unchanged
+	IOPriorityLow                      // lowest best-effort level
+	IOPriorityIdle                     // only when no other process use the disk
+)
+
+// WithBackgroundPriority run the background worker with the niceness nice, 1 to 19 lowers the
+// CPU priority, and the I/O priority io, so compression and cleanup don't compete with writes.
+// It is applied on Linux only, to the worker thread and to the threads of the goroutines running
+// timed out tasks and deletions, errors are reported like background errors.
+func WithBackgroundPriority(nice int, io IOPriority) RotateOption {
+	return func(o *rotateOption) {
+		o.nice = nice
+		o.ioPriority = io
+	}
+}
+
+// lowerPriority apply WithBackgroundPriority to the calling goroutine, which stay on its thread,
+// the thread exits with the goroutine so the priority never reach other goroutines
+func (r *RotateWriter) lowerPriority() {
+	if r.opt.nice == 0 && r.opt.ioPriority == IOPriorityNormal {
+		return
+	}
+	if err := setThreadPriority(r.opt.nice, r.opt.ioPriority); err != nil {
+		r.setErr(err)
+	}
+}
@@ -0,0 +1,43 @@
+//go:build linux
+// +build linux
+
+package rotate
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+const (
+	ioprioWhoProcess    = 1
+	ioprioClassShift    = 13
+	ioprioClassBE       = 2
+	ioprioClassIdle     = 3
+	ioprioLowestBELevel = 7
+)
+
+// setThreadPriority lock the goroutine to its thread and lower the priorities of the thread,
+// the thread is never reused by other goroutines since it is not unlocked
+func setThreadPriority(nice int, io IOPriority) error {
+	runtime.LockOSThread()
+	tid := syscall.Gettid()
+	if nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, tid, nice); err != nil {
+			return os.NewSyscallError("setpriority", err)
+		}
+	}
+	var prio uintptr
+	switch io {
+	case IOPriorityLow:
+		prio = ioprioClassBE<<ioprioClassShift | ioprioLowestBELevel
+	case IOPriorityIdle:
+		prio = ioprioClassIdle << ioprioClassShift
+	default:
+		return nil
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), prio); errno != 0 {
+		return os.NewSyscallError("ioprio_set", errno)
+	}
+	return nil
+}
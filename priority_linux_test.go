@@ -0,0 +1,68 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSetThreadPriority(t *testing.T) {
+	type result struct {
+		prio, ioprio int
+		err          error
+	}
+	ch := make(chan result)
+	go func() {
+		if err := setThreadPriority(3, IOPriorityIdle); err != nil {
+			ch <- result{err: err}
+			return
+		}
+		tid := syscall.Gettid()
+		prio, err := syscall.Getpriority(syscall.PRIO_PROCESS, tid)
+		ioprio, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_GET, ioprioWhoProcess, uintptr(tid), 0)
+		if err == nil && errno != 0 {
+			err = errno
+		}
+		ch <- result{prio: prio, ioprio: int(ioprio), err: err}
+	}()
+	res := <-ch
+	if res.err != nil {
+		t.Skipf("priorities not available: %v", res.err)
+	}
+	// the raw getpriority syscall return 20 - nice
+	if res.prio != 20-3 {
+		t.Errorf("nice incorrect, got:%d", 20-res.prio)
+	}
+	if res.ioprio>>ioprioClassShift != ioprioClassIdle {
+		t.Errorf("io priority incorrect, got:%#x", res.ioprio)
+	}
+}
+
+func TestRotateWriter_runTask_priority(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "priority")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithBackgroundPriority(3, IOPriorityNormal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	var prio int
+	err = writer.runTask(time.Second, "test", "", func() (err error) {
+		prio, err = syscall.Getpriority(syscall.PRIO_PROCESS, syscall.Gettid())
+		return err
+	})
+	if err != nil {
+		t.Skipf("priorities not available: %v", err)
+	}
+	// the raw getpriority syscall return 20 - nice
+	if prio != 20-3 {
+		t.Errorf("nice of a task goroutine incorrect, got:%d", 20-prio)
+	}
+}
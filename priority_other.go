@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package rotate
+
+// setThreadPriority
+func setThreadPriority(nice int, io IOPriority) error {
+	return nil
+}
@@ -0,0 +1,211 @@
+// Package prometheus provide a rotate.MetricsSink served in the Prometheus text exposition format.
+//
+// The package does not depend on the Prometheus client, mount the Sink on the /metrics handler,
+// or next to the client registry under another path.
+package prometheus
+
+import (
+	"fmt"
+	"github.com/AlfredAlan/rotate"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultNamespace = "rotate"
+
+type (
+	// Sink accumulate the writer metrics, counters are exported as name_total, gauges as is, and
+	// timings as a summary without quantiles, name_seconds_sum and name_seconds_count
+	Sink struct {
+		namespace string
+		mu        sync.Mutex
+		counters  map[series]int64
+		gauges    map[series]float64
+		timings   map[series]timing
+	}
+
+	// series is a metric name and its rendered labels
+	series struct {
+		name   string
+		labels string
+	}
+
+	// labeledSink report to the Sink with labels
+	labeledSink struct {
+		sink   *Sink
+		labels string
+	}
+
+	timing struct {
+		sum   time.Duration
+		count int64
+	}
+)
+
+var (
+	_ rotate.LabeledSink = (*Sink)(nil)
+	_ http.Handler       = (*Sink)(nil)
+)
+
+// NewSink prefix the metric names with namespace, default to rotate
+func NewSink(namespace string) *Sink {
+	if len(namespace) == 0 {
+		namespace = defaultNamespace
+	}
+	return &Sink{
+		namespace: namespace,
+		counters:  make(map[series]int64),
+		gauges:    make(map[series]float64),
+		timings:   make(map[series]timing),
+	}
+}
+
+// Counter
+func (s *Sink) Counter(name string, delta int64) {
+	s.counter(series{name: name}, delta)
+}
+
+// Gauge
+func (s *Sink) Gauge(name string, value float64) {
+	s.gauge(series{name: name}, value)
+}
+
+// Timing
+func (s *Sink) Timing(name string, d time.Duration) {
+	s.timing(series{name: name}, d)
+}
+
+// WithLabels return a sink exporting the metrics with labels, the series of every writer share the Sink
+func (s *Sink) WithLabels(labels map[string]string) rotate.MetricsSink {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, labels[k]))
+	}
+	return &labeledSink{sink: s, labels: strings.Join(pairs, ",")}
+}
+
+// counter
+func (s *Sink) counter(key series, delta int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters[key] += delta
+}
+
+// gauge
+func (s *Sink) gauge(key series, value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gauges[key] = value
+}
+
+// timing
+func (s *Sink) timing(key series, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.timings[key]
+	t.sum += d
+	t.count++
+	s.timings[key] = t
+}
+
+// Counter
+func (l *labeledSink) Counter(name string, delta int64) {
+	l.sink.counter(series{name: name, labels: l.labels}, delta)
+}
+
+// Gauge
+func (l *labeledSink) Gauge(name string, value float64) {
+	l.sink.gauge(series{name: name, labels: l.labels}, value)
+}
+
+// Timing
+func (l *labeledSink) Timing(name string, d time.Duration) {
+	l.sink.timing(series{name: name, labels: l.labels}, d)
+}
+
+// ServeHTTP
+func (s *Sink) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = s.Expose(w)
+}
+
+// Expose write the metrics in the text exposition format, sorted by name then labels
+func (s *Sink) Expose(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var (
+		buf  strings.Builder
+		last string
+	)
+	typeLine := func(metric, kind string) {
+		if metric != last {
+			fmt.Fprintf(&buf, "# TYPE %s %s\n", metric, kind)
+			last = metric
+		}
+	}
+	for _, key := range sortedKeys(s.counters) {
+		metric := s.metricName(key.name) + "_total"
+		typeLine(metric, "counter")
+		fmt.Fprintf(&buf, "%s%s %d\n", metric, key.braces(), s.counters[key])
+	}
+	for _, key := range sortedKeys(s.gauges) {
+		metric := s.metricName(key.name)
+		typeLine(metric, "gauge")
+		fmt.Fprintf(&buf, "%s%s %g\n", metric, key.braces(), s.gauges[key])
+	}
+	for _, key := range sortedKeys(s.timings) {
+		metric, t := s.metricName(key.name)+"_seconds", s.timings[key]
+		typeLine(metric, "summary")
+		fmt.Fprintf(&buf, "%s_sum%s %g\n%s_count%s %d\n", metric, key.braces(), t.sum.Seconds(), metric, key.braces(), t.count)
+	}
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// metricName
+func (s *Sink) metricName(name string) string {
+	return s.namespace + "_" + name
+}
+
+// braces return the labels of the series between braces, empty without labels
+func (k series) braces() string {
+	if len(k.labels) == 0 {
+		return ""
+	}
+	return "{" + k.labels + "}"
+}
+
+// sortedKeys
+func sortedKeys(m interface{}) []series {
+	var keys []series
+	switch m := m.(type) {
+	case map[series]int64:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[series]float64:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[series]timing:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].labels < keys[j].labels
+	})
+	return keys
+}
@@ -0,0 +1,40 @@
+package prometheus
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSink(t *testing.T) {
+	sink := NewSink("")
+	sink.Counter("writes", 2)
+	sink.Counter("writes", 3)
+	sink.Gauge("queue_depth", 1)
+	sink.Timing("compress", 500*time.Millisecond)
+	sink.Timing("compress", time.Second)
+
+	rec := httptest.NewRecorder()
+	sink.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	want := "# TYPE rotate_writes_total counter\nrotate_writes_total 5\n" +
+		"# TYPE rotate_queue_depth gauge\nrotate_queue_depth 1\n" +
+		"# TYPE rotate_compress_seconds summary\nrotate_compress_seconds_sum 1.5\nrotate_compress_seconds_count 2\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("exposition incorrect, got:%q", got)
+	}
+}
+
+func TestSink_WithLabels(t *testing.T) {
+	sink := NewSink("")
+	sink.WithLabels(map[string]string{"writer": "app"}).Counter("writes", 2)
+	sink.WithLabels(map[string]string{"writer": "access", "env": "prod"}).Counter("writes", 1)
+	sink.Counter("writes", 3)
+
+	rec := httptest.NewRecorder()
+	sink.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	want := "# TYPE rotate_writes_total counter\nrotate_writes_total 3\n" +
+		"rotate_writes_total{env=\"prod\",writer=\"access\"} 1\nrotate_writes_total{writer=\"app\"} 2\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("exposition incorrect, got:%q", got)
+	}
+}
@@ -0,0 +1,164 @@
+package rotate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+const defaultQueueSize = 100
+
+// OverflowPolicy decide what rotation does when the post rotation queue is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock wait for room in the queue, blocking the write path
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDrop skip compression, upload and retention of the backup, report ErrQueueFull
+	// to the next Write and to subscribers
+	OverflowDrop
+	// OverflowSpill leave the backup on disk, it is processed once the queue drains, the
+	// uncompressed backups of a previous run are compressed at start
+	OverflowSpill
+)
+
+var ErrQueueFull = errors.New("error: post rotation queue is full")
+
+// WithQueueSize set the capacity of the post rotation queue, default 100
+func WithQueueSize(n int) RotateOption {
+	return func(o *rotateOption) {
+		if n <= 0 {
+			o.queueSize = defaultQueueSize
+			return
+		}
+		o.queueSize = n
+	}
+}
+
+// WithOverflowPolicy set what happens when the post rotation queue is full, default OverflowBlock
+func WithOverflowPolicy(p OverflowPolicy) RotateOption {
+	return func(o *rotateOption) {
+		o.overflow = p
+	}
+}
+
+// enqueue hand a backup to the background worker, it must be called while holding the lock
+func (r *RotateWriter) enqueue(b backup) {
+	plain := b.name
+	if b.compressed {
+		plain = b.plain
+	}
+	r.markInflight(plain)
+	r.addTask()
+	if r.opt.overflow == OverflowBlock {
+		r.postCh <- b
+		return
+	}
+	select {
+	case r.postCh <- b:
+		return
+	default:
+		r.doneTask()
+	}
+	if r.opt.overflow == OverflowSpill {
+		r.spillMu.Lock()
+		r.spills = append(r.spills, b)
+		r.spillMu.Unlock()
+		return
+	}
+	r.doneInflight(plain)
+	r.setErr(ErrQueueFull)
+	r.publish(RotateEvent{Backup: b.name, Start: b.start, End: b.end, Err: ErrQueueFull})
+	r.finishRotation(plain, b.name, ErrQueueFull)
+}
+
+// loadSpilled pick up the uncompressed backups left by a previous run, called before afterRotate
+// starts so backups queued later are never among them
+func (r *RotateWriter) loadSpilled() {
+	if !r.opt.gzip {
+		return
+	}
+	files, err := r.allBackupFiles()
+	if err != nil {
+		r.setErr(err)
+		return
+	}
+	skip := make(map[string]bool, len(files)+len(r.recovered))
+	for _, file := range files {
+		if strings.HasSuffix(file, ".gz") {
+			skip[strings.TrimSuffix(file, ".gz")] = true
+		}
+	}
+	// compressed by processRecovered
+	for _, file := range r.recovered {
+		skip[file] = true
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file, ".gz") || skip[file] || r.isDelayed(file) {
+			continue
+		}
+		r.markInflight(file)
+		r.spills = append(r.spills, backup{name: file})
+	}
+}
+
+// processSpilled handle the backups left unqueued by OverflowSpill, only called by afterRotate
+func (r *RotateWriter) processSpilled(delay *time.Timer) {
+	r.spillMu.Lock()
+	spills := r.spills
+	r.spills = nil
+	r.spillMu.Unlock()
+	for _, b := range spills {
+		r.handle(b, delay)
+	}
+}
+
+// WithMaxPendingBackups block Write while n or more backups wait for the background worker,
+// so uncompressed backups do not pile up on a slow disk, zero means unbounded
+func WithMaxPendingBackups(n int) RotateOption {
+	return func(o *rotateOption) {
+		o.maxPending = n
+	}
+}
+
+// waitForBackups block until the worker catch up or ctx is done, it must be called without holding the lock
+func (r *RotateWriter) waitForBackups(ctx context.Context) error {
+	if r.opt.maxPending <= 0 {
+		return nil
+	}
+	if ctx.Done() != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-ctx.Done():
+				r.wakeWriters()
+			case <-stop:
+			}
+		}()
+	}
+	r.taskMu.Lock()
+	defer r.taskMu.Unlock()
+	for r.pending >= r.opt.maxPending && !r.done.Load() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r.taskCond.Wait()
+	}
+	return nil
+}
+
+// wakeWriters release the writes blocked by waitForBackups
+func (r *RotateWriter) wakeWriters() {
+	r.taskMu.Lock()
+	defer r.taskMu.Unlock()
+	r.taskCond.Broadcast()
+}
+
+// queueDepth
+func (r *RotateWriter) queueDepth() int64 {
+	r.taskMu.Lock()
+	defer r.taskMu.Unlock()
+	return int64(r.pending)
+}
@@ -0,0 +1,201 @@
+package rotate
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_overflowPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+
+	t.Run("drop", func(t *testing.T) {
+		uploader := &blockingUploader{release: make(chan struct{})}
+		writer, err := NewRotateWriter(filename, WithQueueSize(1), WithOverflowPolicy(OverflowDrop),
+			WithUploader(uploader))
+		if err != nil {
+			t.Fatal(err)
+		}
+		events, cancel := writer.Subscribe()
+		defer cancel()
+		// the worker holds one backup in the upload and the queue one more
+		for i := 0; i < 3; i++ {
+			if _, err := writer.Rotate(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		dropped := false
+		for !dropped {
+			select {
+			case e := <-events:
+				dropped = e.Err == ErrQueueFull
+			case <-time.After(time.Second):
+				t.Fatal("overflow should publish an error event")
+			}
+		}
+		close(uploader.release)
+		if err := writer.Close(); !errors.Is(err, ErrQueueFull) {
+			t.Errorf("overflow should report ErrQueueFull, got:%v", err)
+		}
+	})
+
+	t.Run("block with failing uploader", func(t *testing.T) {
+		dir, err := ioutil.TempDir(os.TempDir(), "queue")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		uploader := &flakyUploader{fail: true}
+		writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithQueueSize(1),
+			WithUploader(uploader), WithClock(&stepClock{now: time.Now()}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the worker records upload errors while the writer waits for room in the queue
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 10; i++ {
+				_, _ = writer.Write([]byte("test\n"))
+				_, _ = writer.Rotate()
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("a full queue and a failing upload should not deadlock the writer")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := writer.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Close(); err == nil {
+			t.Error("upload errors should be reported by Close")
+		}
+	})
+
+	t.Run("spill queued", func(t *testing.T) {
+		dir, err := ioutil.TempDir(os.TempDir(), "queue")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		uploader := &blockingUploader{release: make(chan struct{})}
+		writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithQueueSize(1),
+			WithOverflowPolicy(OverflowSpill), WithUploader(uploader), WithClock(&stepClock{now: time.Now()}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer writer.Close()
+		for i := 0; i < 5; i++ {
+			if _, err := writer.Write([]byte("test\n")); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := writer.Rotate(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		close(uploader.release)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := writer.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+		// a spilled backup is processed once, never while it is still queued
+		if _, err := writer.Write([]byte("test\n")); err != nil {
+			t.Errorf("write after spill incorrect, got:%v", err)
+		}
+		backups, err := writer.Backups()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, b := range backups {
+			if !b.Compressed {
+				t.Errorf("backup should be compressed, got:%+v", b)
+			}
+		}
+	})
+
+	t.Run("spill", func(t *testing.T) {
+		// an uncompressed backup spilled by an earlier run
+		spilled := mockBackupName(filename, time.Now().AddDate(0, 0, -1).Format(defaultTimeFormat))
+		if err := ioutil.WriteFile(spilled, []byte("test"), defaultFilePerm); err != nil {
+			t.Fatal(err)
+		}
+		writer, err := NewRotateWriter(filename, WithGzip(true), WithOverflowPolicy(OverflowSpill))
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(spilled + ".gz"); err != nil {
+			t.Errorf("spilled backup should be compressed at start: %v", err)
+		}
+	})
+}
+
+type blockingUploader struct {
+	release chan struct{}
+}
+
+func (b *blockingUploader) Upload(_ context.Context, filename string) (string, error) {
+	<-b.release
+	return "mock://" + filename, nil
+}
+
+func TestRotateWriter_maxPendingBackups(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	uploader := &blockingUploader{release: make(chan struct{})}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithUploader(uploader), WithMaxPendingBackups(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if depth := writer.Stats().QueueDepth; depth != 1 {
+		t.Errorf("queue depth incorrect, got:%v", depth)
+	}
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		if _, err := writer.Write([]byte("test\n")); err != nil {
+			t.Error(err)
+		}
+	}()
+	select {
+	case <-written:
+		t.Fatal("write should block while the backup is pending")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(uploader.release)
+	select {
+	case <-written:
+	case <-time.After(time.Second):
+		t.Fatal("write should resume once the backup is processed")
+	}
+	if depth := writer.Stats().QueueDepth; depth != 0 {
+		t.Errorf("queue depth incorrect, got:%v", depth)
+	}
+}
@@ -0,0 +1,102 @@
+package rotate
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrQuotaExceeded = errors.New("error: directory quota exceeded")
+
+// WithDirectoryQuota cap the total size of the active file and its backups to bytes, the oldest
+// backups are removed by the background worker after every rotation and whenever a write goes
+// over the quota, backups still queued or processed are never removed. Writes that would exceed
+// the quota even without any backup fail with ErrQuotaExceeded.
+func WithDirectoryQuota(bytes int64) RotateOption {
+	return func(o *rotateOption) {
+		o.quota = bytes
+	}
+}
+
+// checkQuota ask the worker to prune backups when size more bytes go over the quota, it must be
+// called while holding the lock
+func (r *RotateWriter) checkQuota(size int64) error {
+	if r.opt.quota <= 0 || r.size+r.backupBytes.Load()+size <= r.opt.quota {
+		return nil
+	}
+	if r.size+size > r.opt.quota {
+		return ErrQuotaExceeded
+	}
+	select {
+	case r.pruneCh <- struct{}{}:
+	default:
+		// a prune is already requested
+	}
+	return nil
+}
+
+// enforceQuota remove the oldest backups until the active file and backups fit in the quota
+func (r *RotateWriter) enforceQuota() {
+	if r.opt.quota <= 0 {
+		return
+	}
+	if err := r.pruneToQuota(0); err != nil {
+		r.setErr(err)
+	}
+}
+
+// pruneToQuota remove the oldest backups until reserve more bytes fit in the quota
+func (r *RotateWriter) pruneToQuota(reserve int64) error {
+	backups, err := r.Backups()
+	if err != nil {
+		return err
+	}
+	// the logical size, bytes still buffered will land in the active file
+	total := r.stats.activeSize.Load()
+	for _, b := range backups {
+		total += b.Size
+	}
+
+	var (
+		removed  []string
+		pruned   int64
+		overflow = total + reserve - r.opt.quota
+	)
+	for _, b := range backups {
+		if pruned >= overflow {
+			break
+		}
+		// the worker still needs it
+		if r.isInflight(strings.TrimSuffix(b.Name, ".gz")) {
+			continue
+		}
+		removed = append(removed, b.Name)
+		pruned += b.Size
+	}
+	err = r.removeFiles(removed)
+	r.backupBytes.Store(total - r.stats.activeSize.Load() - pruned)
+	return err
+}
+
+// markInflight record that the backup plain is queued or processed by the worker
+func (r *RotateWriter) markInflight(plain string) {
+	r.inflightMu.Lock()
+	defer r.inflightMu.Unlock()
+	if r.inflight == nil {
+		r.inflight = make(map[string]bool)
+	}
+	r.inflight[plain] = true
+}
+
+// doneInflight
+func (r *RotateWriter) doneInflight(plain string) {
+	r.inflightMu.Lock()
+	defer r.inflightMu.Unlock()
+	delete(r.inflight, plain)
+}
+
+// isInflight
+func (r *RotateWriter) isInflight(plain string) bool {
+	r.inflightMu.Lock()
+	defer r.inflightMu.Unlock()
+	return r.inflight[plain]
+}
@@ -0,0 +1,112 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type stepClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// Now move one second forward on every call so each backup gets a distinct name
+func (c *stepClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(time.Second)
+	return c.now
+}
+
+func TestRotateWriter_WithDirectoryQuota(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "quota")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithDirectoryQuota(30), WithClock(&stepClock{now: time.Now()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for i := 0; i < 4; i++ {
+		if _, err := writer.Write([]byte("123456789\n")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 3 {
+		t.Errorf("quota should keep 3 backups, got:%v", len(backups))
+	}
+
+	if _, err := writer.Write(make([]byte, 31)); err != ErrQuotaExceeded {
+		t.Errorf("write beyond the quota incorrect, got:%v", err)
+	}
+}
+
+func TestRotateWriter_WithDirectoryQuota_inflight(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "quota")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	uploader := &blockingUploader{release: make(chan struct{})}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithDirectoryQuota(30), WithUploader(uploader),
+		WithClock(&stepClock{now: time.Now()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for i := 0; i < 2; i++ {
+		if _, err := writer.Write([]byte("123456789\n")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// over the quota, the pruning goes to the worker and skips the queued backups
+	if _, err := writer.Write([]byte("123456789\n123\n")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Errorf("queued backups should be kept, got:%v", len(backups))
+	}
+
+	close(uploader.release)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if backups, err = writer.Backups(); err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Errorf("quota should keep 1 backup once processed, got:%v", len(backups))
+	}
+	if err := writer.Close(); err != nil {
+		t.Errorf("pruning should not report missing backups, got:%v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package rotate
+
+import (
+	"io"
+	"time"
+)
+
+// rangeReader keep the lines of a LineIterator whose timestamp is within [from, to],
+// lines without a timestamp follow the decision of the previous line
+type rangeReader struct {
+	it       *LineIterator
+	lineTime func(line []byte) (time.Time, bool)
+	from, to time.Time
+	keep     bool
+	pending  []byte
+}
+
+var _ io.ReadCloser = (*rangeReader)(nil)
+
+// WithLineTimestamp extract the timestamp of a line, ReadRange use it to drop lines out of range
+func WithLineTimestamp(fn func(line []byte) (time.Time, bool)) RotateOption {
+	return func(o *rotateOption) {
+		o.lineTime = fn
+	}
+}
+
+// ReadRange return a reader over the files that may contain data written within [from, to],
+// files are selected with the timestamp in their name, lines are filtered too if WithLineTimestamp is set
+func (r *RotateWriter) ReadRange(from, to time.Time) (io.ReadCloser, error) {
+	names, err := r.rangeFiles(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if r.opt.lineTime == nil {
+		return &backupReader{names: names, open: r.openBackup}, nil
+	}
+	return &rangeReader{
+		it:       &LineIterator{names: names, open: r.openBackup, maxLine: int(r.maxRecord())},
+		lineTime: r.opt.lineTime,
+		from:     from,
+		to:       to,
+	}, nil
+}
+
+// rangeFiles a backup covers the time from the timestamp in its name to its modification time,
+// the live file covers the time after the newest backup
+func (r *RotateWriter) rangeFiles(from, to time.Time) ([]string, error) {
+	backups, err := r.Backups()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(backups)+1)
+	var liveStart time.Time
+	for _, b := range backups {
+		if b.ModTime.After(liveStart) {
+			liveStart = b.ModTime
+		}
+		// keep backups whose name can't be parsed, they may be in range
+		if !b.Timestamp.IsZero() && (b.Timestamp.After(to) || b.ModTime.Before(from)) {
+			continue
+		}
+		names = append(names, b.Name)
+	}
+	if !liveStart.After(to) {
+		filename, _, _ := r.paths()
+		names = append(names, filename)
+	}
+	return names, nil
+}
+
+// Read
+func (rr *rangeReader) Read(p []byte) (int, error) {
+	for len(rr.pending) == 0 {
+		line, err := rr.it.Next()
+		if err != nil {
+			return 0, err
+		}
+		if ts, ok := rr.lineTime(line); ok {
+			rr.keep = !ts.Before(rr.from) && !ts.After(rr.to)
+		}
+		if rr.keep {
+			rr.pending = append(append(rr.pending[:0], line...), '\n')
+		}
+	}
+	n := copy(p, rr.pending)
+	rr.pending = rr.pending[n:]
+	return n, nil
+}
+
+// Close
+func (rr *rangeReader) Close() error {
+	return rr.it.Close()
+}
@@ -0,0 +1,79 @@
+package rotate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_ReadRange(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if _, err := tmpFile.WriteString("3 live\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local)
+	writer, err := NewRotateWriter(
+		tmpFileName,
+		WithLineTimestamp(func(line []byte) (time.Time, bool) {
+			if len(line) == 0 || line[0] < '0' || line[0] > '9' {
+				return time.Time{}, false
+			}
+			return base.Add(time.Duration(line[0]-'0') * time.Hour), true
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	// one backup per hour, each modified at the end of its hour
+	for i, content := range []string{"0 a\n", "1 b\n  trace\n2 c\n"} {
+		name := mockBackupName(tmpFileName, base.Add(time.Duration(i)*time.Hour).Format(writer.opt.timeFormat))
+		if err := ioutil.WriteFile(name, []byte(content), defaultFilePerm); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(name)
+		mtime := base.Add(time.Duration(i+1)*time.Hour - time.Minute)
+		if err := os.Chtimes(name, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reader, err := writer.ReadRange(base.Add(time.Hour+30*time.Minute), base.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, []byte("2 c\n")) {
+		t.Errorf("range content incorrect, got:%q", got)
+	}
+
+	names, err := writer.rangeFiles(base, base.Add(30*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 {
+		t.Errorf("range files incorrect, got:%v", names)
+	}
+}
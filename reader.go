@@ -0,0 +1,188 @@
+package rotate
+
+import (
+	"bufio"
+	"compress/gzip"
+	"go.uber.org/multierr"
+	"io"
+	"os"
+	"strings"
+)
+
+// backupReader concatenate files, each file is opened only when the previous one is exhausted
+type backupReader struct {
+	names []string
+	open  func(name string) (io.ReadCloser, error)
+	cur   io.ReadCloser
+}
+
+var _ io.ReadCloser = (*backupReader)(nil)
+
+// OpenReader return a reader over all backups in time order followed by the current file,
+// compressed backups are decompressed transparently
+func (r *RotateWriter) OpenReader() (io.ReadCloser, error) {
+	names, err := r.readOrder()
+	if err != nil {
+		return nil, err
+	}
+	return &backupReader{names: names, open: r.openBackup}, nil
+}
+
+// readOrder return backups in time order followed by the current file
+func (r *RotateWriter) readOrder() ([]string, error) {
+	backups, err := r.Backups()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(backups)+1)
+	for _, b := range backups {
+		names = append(names, b.Name)
+	}
+	filename, _, _ := r.paths()
+	return append(names, filename), nil
+}
+
+// Read
+func (b *backupReader) Read(p []byte) (int, error) {
+	for {
+		if b.cur == nil {
+			if len(b.names) == 0 {
+				return 0, io.EOF
+			}
+			fp, err := b.open(b.names[0])
+			b.names = b.names[1:]
+			if os.IsNotExist(err) {
+				// removed by retention after the names were listed
+				continue
+			} else if err != nil {
+				return 0, err
+			}
+			b.cur = fp
+		}
+		n, err := b.cur.Read(p)
+		if err == io.EOF {
+			err = b.cur.Close()
+			b.cur = nil
+			if n > 0 || err != nil {
+				return n, err
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// Close
+func (b *backupReader) Close() error {
+	b.names = nil
+	if b.cur == nil {
+		return nil
+	}
+	err := b.cur.Close()
+	b.cur = nil
+	return err
+}
+
+// gzipReadCloser close both the gzip reader and the underlying file
+type gzipReadCloser struct {
+	*gzip.Reader
+	fp *os.File
+}
+
+// Close
+func (g *gzipReadCloser) Close() error {
+	return multierr.Append(g.Reader.Close(), g.fp.Close())
+}
+
+// openBackup open name for reading, decompressing it if it is compressed, a plain backup
+// compressed in the background after it was listed is opened as name.gz
+func (r *RotateWriter) openBackup(name string) (io.ReadCloser, error) {
+	fp, err := os.Open(name)
+	if os.IsNotExist(err) && !strings.HasSuffix(name, ".gz") && !r.recompressed(name) {
+		name += ".gz"
+		fp, err = os.Open(name)
+	}
+	if os.IsNotExist(err) && strings.HasSuffix(name, ".gz") && r.opt.recompress != nil {
+		// recompressed after it was listed
+		name = strings.TrimSuffix(name, ".gz") + r.opt.recompress.Ext()
+		fp, err = os.Open(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.recompressed(name) {
+		dec, err := r.opt.recompress.NewReader(fp)
+		if err != nil {
+			return nil, multierr.Append(err, fp.Close())
+		}
+		return &codecReadCloser{ReadCloser: dec, fp: fp}, nil
+	}
+	if !strings.HasSuffix(name, ".gz") {
+		return fp, nil
+	}
+	gz, err := gzip.NewReader(fp)
+	if err != nil {
+		return nil, multierr.Append(err, fp.Close())
+	}
+	return &gzipReadCloser{Reader: gz, fp: fp}, nil
+}
+
+// LineIterator yield lines across backups and the live file in order
+type LineIterator struct {
+	names   []string
+	open    func(name string) (io.ReadCloser, error)
+	maxLine int
+	cur     io.ReadCloser
+	scanner *bufio.Scanner
+}
+
+// Lines return an iterator over the lines of all backups and the live file,
+// a file not ending with a newline still ends its last line
+func (r *RotateWriter) Lines() (*LineIterator, error) {
+	names, err := r.readOrder()
+	if err != nil {
+		return nil, err
+	}
+	return &LineIterator{names: names, open: r.openBackup, maxLine: int(r.maxRecord())}, nil
+}
+
+// Next return the next line without the trailing newline, io.EOF after the last line,
+// the returned slice is only valid until the next call
+func (it *LineIterator) Next() ([]byte, error) {
+	for {
+		if it.cur == nil {
+			if len(it.names) == 0 {
+				return nil, io.EOF
+			}
+			fp, err := it.open(it.names[0])
+			it.names = it.names[1:]
+			if os.IsNotExist(err) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			it.cur = fp
+			it.scanner = bufio.NewScanner(fp)
+			it.scanner.Buffer(make([]byte, 0, 64*1024), it.maxLine)
+		}
+		if it.scanner.Scan() {
+			return it.scanner.Bytes(), nil
+		}
+		err := multierr.Append(it.scanner.Err(), it.cur.Close())
+		it.cur, it.scanner = nil, nil
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Close
+func (it *LineIterator) Close() error {
+	it.names = nil
+	if it.cur == nil {
+		return nil
+	}
+	err := it.cur.Close()
+	it.cur, it.scanner = nil, nil
+	return err
+}
@@ -0,0 +1,156 @@
+package rotate
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_OpenReader(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(tmpFileName, WithGzip(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backupName := writer.backupName
+
+	if _, err := writer.Write([]byte("first\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	defer os.Remove(backupName + ".gz")
+	if _, err := writer.Write([]byte("second\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := writer.OpenReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "first\nsecond\n" {
+		t.Errorf("content incorrect, got:%q", got)
+	}
+}
+
+func TestRotateWriter_Lines(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(tmpFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backupName := writer.backupName
+
+	if _, err := writer.Write([]byte("a\nb")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(backupName)
+	if _, err := writer.Write([]byte("c\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	it, err := writer.Lines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	var got []string
+	for {
+		line, err := it.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(line))
+	}
+	if !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("lines incorrect, got:%v", got)
+	}
+}
+
+func TestRotateWriter_Lines_maxMessageSize(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "reader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a record larger than the file size limit
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithMaxSize(1), WithMaxMessageSize(2*megabyte))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	long := append(bytes.Repeat([]byte("a"), 3*megabyte/2), '\n')
+	if _, err := writer.Write(long); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	it, err := writer.Lines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	if line, err := it.Next(); err != nil || len(line) != len(long)-1 {
+		t.Errorf("long line incorrect, got:%d %v", len(line), err)
+	}
+	matches, err := writer.Search(context.Background(), func(line []byte) bool { return true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for m := range matches {
+		if m.Err != nil || len(m.Text) != len(long)-1 {
+			t.Errorf("search of a long line incorrect, got:%d %v", len(m.Text), m.Err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"fmt"
+	"go.uber.org/multierr"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type (
+	// Codec is a compression format stronger than gzip for cold backups, e.g. an adapter
+	// around a zstd or xz package
+	Codec interface {
+		// Ext is appended to recompressed backups instead of .gz, e.g. ".zst"
+		Ext() string
+		NewWriter(w io.Writer) (io.WriteCloser, error)
+		NewReader(r io.Reader) (io.ReadCloser, error)
+	}
+
+	// codecReadCloser close both the decoder and the file
+	codecReadCloser struct {
+		io.ReadCloser
+		fp *os.File
+	}
+)
+
+// Close
+func (c *codecReadCloser) Close() error {
+	return multierr.Append(c.ReadCloser.Close(), c.fp.Close())
+}
+
+// WithRecompression recompress gzip backups older than days with codec in the background,
+// it requires WithGzip. Readers and retention handle recompressed backups transparently.
+func WithRecompression(days int64, codec Codec) RotateOption {
+	return func(o *rotateOption) {
+		o.recompressDays = days
+		o.recompress = codec
+	}
+}
+
+// recompressed report whether file was recompressed by the codec
+func (r *RotateWriter) recompressed(file string) bool {
+	return r.opt.recompress != nil && strings.HasSuffix(file, r.opt.recompress.Ext())
+}
+
+// appendRecompressed add the recompressed backups to files
+func (r *RotateWriter) appendRecompressed(files []string) ([]string, error) {
+	if r.opt.recompress == nil {
+		return files, nil
+	}
+	_, prefix, ext := r.paths()
+	pattern := fmt.Sprintf("%s%s%s", globEscape(prefix+r.opt.delimiter), dateGlob(r.opt.timeFormat), globEscape(ext+r.opt.recompress.Ext()))
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return []string{}, err
+	}
+	return append(files, matches...), nil
+}
+
+// recompressOld recompress the gzip backups older than the recompression age
+func (r *RotateWriter) recompressOld() {
+	if r.opt.recompress == nil || !r.opt.gzip {
+		return
+	}
+	backups, err := r.Backups()
+	if err != nil {
+		r.setErr(err)
+		return
+	}
+	cutoff := r.now().Add(-time.Hour * time.Duration(24*r.opt.recompressDays))
+	for _, b := range backups {
+		if !strings.HasSuffix(b.Name, ".gz") || !b.sortTime().Before(cutoff) {
+			continue
+		}
+		if err = r.recompressFile(b.Name); err != nil {
+			r.setErr(err)
+			return
+		}
+	}
+}
+
+// recompressFile convert the gzip backup name to the codec and remove it
+func (r *RotateWriter) recompressFile(name string) (err error) {
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Append(err, in.Close())
+	}()
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return err
+	}
+
+	target := strings.TrimSuffix(name, ".gz") + r.opt.recompress.Ext()
+	tmp := target + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w, err := r.opt.recompress.NewWriter(out)
+	if err == nil {
+		_, err = io.Copy(w, gz)
+		err = multierr.Append(err, w.Close())
+	}
+	if err = multierr.Combine(err, gz.Close(), out.Close()); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err = os.Rename(tmp, target); err != nil {
+		return err
+	}
+	if err = r.chmodBackup(target); err != nil {
+		return err
+	}
+	if err = r.protectBackup(target); err != nil {
+		return err
+	}
+	// the recompressed backup holds the same span as the one it replaces
+	start, end, ok, err := r.indexSpan(name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if start, err = r.parseBackupTime(target); err != nil {
+			return r.removeBackup(name)
+		}
+		end = start
+	}
+	if err = r.indexAdd(target, start, end); err != nil {
+		return err
+	}
+	return r.removeBackup(name)
+}
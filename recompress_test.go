@@ -0,0 +1,127 @@
+package rotate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type bestGzip struct{}
+
+func (bestGzip) Ext() string { return ".gz9" }
+
+func (bestGzip) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, gzip.BestCompression)
+}
+
+func (bestGzip) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+func TestRotateWriter_WithRecompression(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "recompress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+	old := mockBackupName(filename, time.Now().AddDate(0, 0, -3).Format(defaultTimeFormat))
+	if err := ioutil.WriteFile(old, []byte("old\n"), defaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipFile(old); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(filename, WithGzip(true), WithRecompression(2, bestGzip{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := writer.Write([]byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(old + ".gz9"); err != nil {
+		t.Errorf("old backup should be recompressed: %v", err)
+	}
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 || !backups[0].Compressed || filepath.Ext(backups[1].Name) != ".gz" {
+		t.Errorf("backups incorrect, got:%+v", backups)
+	}
+	reader, err := writer.OpenReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, []byte("old\nnew\n")) {
+		t.Errorf("read incorrect, got:%q", data)
+	}
+}
+
+func TestRotateWriter_WithRecompression_index(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "recompress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+	start := time.Now().AddDate(0, 0, -3)
+	end := start.Add(time.Hour)
+	old := mockBackupName(filename, start.Format(defaultTimeFormat))
+	if err := ioutil.WriteFile(old, []byte("old\n"), defaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipFile(old); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(filename, WithGzip(true), WithIndex(""), WithRecompression(2, bestGzip{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if err := writer.indexAdd(old+".gz", start, end); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	indexed, err := writer.Query(time.Time{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range indexed {
+		if b.Name != old+".gz9" {
+			continue
+		}
+		if !b.Timestamp.Equal(start) || !b.ModTime.Equal(end) {
+			t.Errorf("recompressed span incorrect, got:%v %v", b.Timestamp, b.ModTime)
+		}
+		return
+	}
+	t.Errorf("recompressed backup should be indexed, got:%+v", indexed)
+}
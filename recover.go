@@ -0,0 +1,103 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// recoverBackups clean up after a compression interrupted by a crash, called once before afterRotate starts.
+// A .gz.tmp is removed, a .gz which does not decompress to the end is removed when its source survived,
+// and a source whose .gz is complete is removed unless WithKeepOriginal. The sources left without a .gz
+// are compressed again by afterRotate.
+func (r *RotateWriter) recoverBackups() {
+	files, err := r.allBackupFiles()
+	if err != nil {
+		r.setErr(err)
+		return
+	}
+	if !r.customBackupPattern() {
+		tmps, err := filepath.Glob(r.backupPattern(true) + ".tmp")
+		if err != nil {
+			r.setErr(err)
+			return
+		}
+		files = append(files, tmps...)
+	}
+
+	present := make(map[string]bool, len(files))
+	for _, file := range files {
+		present[file] = true
+	}
+	resume := make(map[string]bool)
+	for file := range present {
+		source := strings.TrimSuffix(file, ".gz.tmp")
+		if source == file {
+			continue
+		}
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			r.setErr(err)
+			continue
+		}
+		delete(present, file)
+		resume[source] = present[source]
+	}
+	for file := range present {
+		source := strings.TrimSuffix(file, ".gz")
+		if source == file || !present[source] {
+			continue
+		}
+		if !gzipComplete(file) {
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				r.setErr(err)
+				continue
+			}
+			resume[source] = true
+		} else if !r.opt.keepOriginal {
+			// the crash hit between the rename and the removal of the source
+			if err := os.Remove(source); err != nil && !os.IsNotExist(err) {
+				r.setErr(err)
+			}
+		}
+	}
+
+	for source, ok := range resume {
+		if ok {
+			r.recovered = append(r.recovered, source)
+		}
+	}
+	sort.Strings(r.recovered)
+}
+
+// processRecovered compress the sources whose compression was interrupted, only called by afterRotate
+func (r *RotateWriter) processRecovered() {
+	if !r.opt.gzip {
+		r.recovered = nil
+		return
+	}
+	for _, file := range r.recovered {
+		if !r.isDelayed(file) {
+			r.process(backup{name: file})
+		}
+	}
+	r.recovered = nil
+}
+
+// gzipComplete report whether filename decompress to the end
+func gzipComplete(filename string) bool {
+	fp, err := os.Open(filename)
+	if err != nil {
+		return false
+	}
+	defer fp.Close()
+	gr, err := gzip.NewReader(fp)
+	if err != nil {
+		return false
+	}
+	_, err = io.Copy(ioutil.Discard, gr)
+	return err == nil && gr.Close() == nil
+}
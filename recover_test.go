@@ -0,0 +1,84 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_recoverBackups(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "recover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Now().Add(-time.Hour)
+	name := func(i int) string {
+		return filepath.Join(dir, "app-"+now.Add(time.Duration(i)*time.Second).Format(defaultTimeFormat)+".log")
+	}
+	write := func(name, data string) {
+		if err := ioutil.WriteFile(name, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// interrupted while compressing
+	write(name(0), "first\n")
+	write(name(0)+".gz.tmp", "partial")
+	// interrupted by a previous version before any byte was compressed
+	write(name(1), "second\n")
+	write(name(1)+".gz", "")
+	// interrupted before the source was removed
+	writeGzip(t, name(2), "third\n")
+	write(name(2), "third\n")
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		for _, leftover := range []string{name(i), name(i) + ".gz.tmp"} {
+			if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+				t.Errorf("%s incorrect, got:%v", leftover, err)
+			}
+		}
+		if !gzipComplete(name(i) + ".gz") {
+			t.Errorf("%s.gz should be complete", name(i))
+		}
+	}
+}
+
+func TestRotateWriter_recoverBackups_keepOriginal(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "recover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	backup := filepath.Join(dir, "app-"+time.Now().Add(-time.Hour).Format(defaultTimeFormat)+".log")
+	writeGzip(t, backup, "test\n")
+	if err := ioutil.WriteFile(backup, []byte("test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithKeepOriginal(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for _, name := range []string{backup, backup + ".gz"} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("%s incorrect, got:%v", name, err)
+		}
+	}
+}
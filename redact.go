@@ -0,0 +1,32 @@
+package rotate
+
+import "regexp"
+
+var (
+	// EmailPattern match email addresses
+	EmailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// CreditCardPattern match 13 to 19 digit card numbers, optionally grouped by spaces or dashes
+	CreditCardPattern = regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`)
+	// BearerTokenPattern match bearer tokens in authorization headers
+	BearerTokenPattern = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9\-._~+/]+=*`)
+)
+
+// WithRedactor apply fn to every record before it is written, see Redact
+func WithRedactor(fn func([]byte) []byte) RotateOption {
+	return func(o *rotateOption) {
+		o.middlewares = append(o.middlewares, func(*rotateOption) Middleware {
+			return Redact(fn)
+		})
+	}
+}
+
+// RegexpRedactor return a redactor replacing every match of patterns with mask
+func RegexpRedactor(mask string, patterns ...*regexp.Regexp) func([]byte) []byte {
+	replacement := []byte(mask)
+	return func(data []byte) []byte {
+		for _, re := range patterns {
+			data = re.ReplaceAllLiteral(data, replacement)
+		}
+		return data
+	}
+}
@@ -0,0 +1,21 @@
+package rotate
+
+import "testing"
+
+func TestRegexpRedactor(t *testing.T) {
+	redact := RegexpRedactor("***", EmailPattern, CreditCardPattern, BearerTokenPattern)
+	tests := []struct {
+		data string
+		want string
+	}{
+		{data: "user alice@example.com logged in", want: "user *** logged in"},
+		{data: "card 4111 1111 1111 1111 charged", want: "card *** charged"},
+		{data: "Authorization: Bearer abc.def-123=", want: "Authorization: ***"},
+		{data: "order 12345 shipped", want: "order 12345 shipped"},
+	}
+	for _, tt := range tests {
+		if got := string(redact([]byte(tt.data))); got != tt.want {
+			t.Errorf("redact %q incorrect, got:%q, want:%q", tt.data, got, tt.want)
+		}
+	}
+}
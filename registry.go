@@ -0,0 +1,129 @@
+package rotate
+
+import (
+	"go.uber.org/multierr"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// registry hold the writers created WithRegistry
+var registry = struct {
+	sync.Mutex
+	writers map[*RotateWriter]struct{}
+}{writers: make(map[*RotateWriter]struct{})}
+
+// WithRegistry add the writer to the package registry until it is closed, so writers created
+// anywhere in the application are reached by RotateAll, FlushAll, CloseAll and HandleSignals
+func WithRegistry(enable bool) RotateOption {
+	return func(o *rotateOption) {
+		o.registry = enable
+	}
+}
+
+// register
+func (r *RotateWriter) register() {
+	if !r.opt.registry {
+		return
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	registry.writers[r] = struct{}{}
+}
+
+// unregister
+func (r *RotateWriter) unregister() {
+	registry.Lock()
+	defer registry.Unlock()
+	delete(registry.writers, r)
+}
+
+// Registered return the writers of the registry
+func Registered() []*RotateWriter {
+	registry.Lock()
+	defer registry.Unlock()
+	writers := make([]*RotateWriter, 0, len(registry.writers))
+	for w := range registry.writers {
+		writers = append(writers, w)
+	}
+	return writers
+}
+
+// RotateAll rotate every registered writer
+func RotateAll() (err error) {
+	for _, w := range Registered() {
+		_, rotateErr := w.Rotate()
+		err = multierr.Append(err, rotateErr)
+	}
+	return err
+}
+
+// FlushAll commit the active file of every registered writer to stable storage
+func FlushAll() (err error) {
+	for _, w := range Registered() {
+		err = multierr.Append(err, w.Sync())
+	}
+	return err
+}
+
+// CloseAll close every registered writer, they leave the registry
+func CloseAll() (err error) {
+	for _, w := range Registered() {
+		err = multierr.Append(err, w.Close())
+	}
+	return err
+}
+
+// HandleSignals hook the signals of every registered writer: SIGHUP rotate, SIGUSR1 flush and
+// SIGTERM or an interrupt close them, then the signal is raised again so the process terminates as
+// it would have. Platforms without SIGHUP and SIGUSR1 only close on an interrupt. Errors go to onErr
+// if not nil. The returned func stop the handling.
+func HandleSignals(onErr func(error)) (stop func()) {
+	rotateSigs, flushSigs, closeSigs := registrySignals()
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, append(append(append([]os.Signal{}, rotateSigs...), flushSigs...), closeSigs...)...)
+	done := make(chan struct{})
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+	report := func(err error) {
+		if err != nil && onErr != nil {
+			onErr(err)
+		}
+	}
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				switch {
+				case containsSignal(rotateSigs, sig):
+					report(RotateAll())
+				case containsSignal(flushSigs, sig):
+					report(FlushAll())
+				default:
+					report(CloseAll())
+					stop()
+					raise(sig)
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return stop
+}
+
+// containsSignal
+func containsSignal(sigs []os.Signal, sig os.Signal) bool {
+	for _, s := range sigs {
+		if s == sig {
+			return true
+		}
+	}
+	return false
+}
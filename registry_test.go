@@ -0,0 +1,65 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clock := &stepClock{now: time.Now()}
+	var registered []*RotateWriter
+	for _, name := range []string{"a.log", "b.log"} {
+		w, err := NewRotateWriter(filepath.Join(dir, name), WithRegistry(true), WithClock(clock))
+		if err != nil {
+			t.Fatal(err)
+		}
+		registered = append(registered, w)
+	}
+	other, err := NewRotateWriter(filepath.Join(dir, "c.log"), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if n := len(Registered()); n != 2 {
+		t.Fatalf("registered incorrect, got:%d", n)
+	}
+
+	for _, w := range append(registered, other) {
+		if _, err := w.Write([]byte("test\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := RotateAll(); err != nil {
+		t.Fatal(err)
+	}
+	for i, w := range append(registered, other) {
+		if rotations := w.Stats().Rotations; rotations != int64(1-i/2) {
+			t.Errorf("rotations of writer %d incorrect, got:%d", i, rotations)
+		}
+	}
+
+	if err := CloseAll(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(Registered()); n != 0 {
+		t.Errorf("registered after CloseAll incorrect, got:%d", n)
+	}
+	if _, err := registered[0].Write([]byte("test\n")); err != ErrLogFileClosed {
+		t.Errorf("write after CloseAll incorrect, got:%v", err)
+	}
+	if _, err := other.Write([]byte("test\n")); err != nil {
+		t.Errorf("unregistered writer should stay open, got:%v", err)
+	}
+}
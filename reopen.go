@@ -0,0 +1,46 @@
+package rotate
+
+import (
+	"go.uber.org/multierr"
+)
+
+// Reopen switch the writer to newPath, the current file is closed without rotation and
+// backups are named and looked up next to newPath from now on. A custom backup pattern
+// is kept as is. If newPath can't be opened the writer goes back to the previous file.
+func (r *RotateWriter) Reopen(newPath string) error {
+	if len(newPath) == 0 {
+		return ErrFileNameIsEmpty
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done.Load() {
+		return ErrLogFileClosed
+	}
+	r.waitFlush()
+	if err := multierr.Append(r.syncFile(), r.fp.Close()); err != nil {
+		return err
+	}
+
+	oldPath := r.filename
+	r.setFilename(newPath)
+	r.partial = false
+	if err := r.init(); err != nil {
+		r.setFilename(oldPath)
+		return multierr.Append(err, r.init())
+	}
+	return nil
+}
+
+// paths return the active file name, the backup prefix and the extension
+func (r *RotateWriter) paths() (filename, prefix, ext string) {
+	r.nameMu.RLock()
+	defer r.nameMu.RUnlock()
+	return r.filename, r.prefix, r.ext
+}
+
+// setFilename
+func (r *RotateWriter) setFilename(filename string) {
+	r.nameMu.Lock()
+	defer r.nameMu.Unlock()
+	r.filename = filename
+}
@@ -0,0 +1,58 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotateWriter_Reopen(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "reopen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	oldPath := filepath.Join(dir, "tenant-a", "app.log")
+	newPath := filepath.Join(dir, "tenant-b", "app.log")
+
+	writer, err := NewRotateWriter(oldPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := writer.Write([]byte("old\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Reopen(""); err != ErrFileNameIsEmpty {
+		t.Errorf("empty path incorrect, got:%v", err)
+	}
+	if err := writer.Reopen(newPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]string{oldPath: "old\n", newPath: "new\n"} {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("%s content incorrect, got:%q", path, data)
+		}
+	}
+
+	backupName := writer.backupName
+	if !strings.HasPrefix(backupName, filepath.Join(dir, "tenant-b")) {
+		t.Errorf("backup name should follow the new path, got:%v", backupName)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(backupName); err != nil {
+		t.Errorf("backup should be next to the new path: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package rotate
+
+import (
+	"bufio"
+	"context"
+	"go.uber.org/multierr"
+	"io"
+)
+
+// Replay write the records of backupName to dst, one Write per record. Records are the frames
+// of WithFraming, written without their length prefix, or lines otherwise, a last line without
+// newline included.
+func (r *RotateWriter) Replay(ctx context.Context, backupName string, dst io.Writer) (err error) {
+	rc, err := r.openBackup(backupName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Append(err, rc.Close())
+	}()
+	next := lineRecords(bufio.NewReader(rc))
+	if r.opt.framing != FramingNone {
+		next = NewFrameReader(rc, r.opt.framing, int(r.maxRecord())).Next
+	}
+	for {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		record, err := next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if _, err = dst.Write(record); err != nil {
+			return err
+		}
+	}
+}
+
+// lineRecords return the lines of br one by one
+func lineRecords(br *bufio.Reader) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF && len(line) > 0 {
+			return line, nil
+		}
+		return line, err
+	}
+}
@@ -0,0 +1,71 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// recordWriter keep every Write as one record
+type recordWriter struct {
+	records []string
+}
+
+func (w *recordWriter) Write(p []byte) (int, error) {
+	w.records = append(w.records, string(p))
+	return len(p), nil
+}
+
+func TestRotateWriter_Replay(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		framing Framing
+		want    []string
+	}{
+		{"lines", FramingNone, []string{"a\n", "b\n", "c"}},
+		{"frames", FramingUvarint, []string{"a\nb\n", "c"}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir(os.TempDir(), "replay")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithFraming(c.framing))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer writer.Close()
+			for _, data := range []string{"a\nb\n", "c"} {
+				if _, err := writer.Write([]byte(data)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := writer.Rotate(); err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := writer.Wait(ctx); err != nil {
+				t.Fatal(err)
+			}
+			backups, err := writer.Backups()
+			if err != nil || len(backups) != 1 {
+				t.Fatalf("backups incorrect, got:%v %v", backups, err)
+			}
+
+			dst := &recordWriter{}
+			if err := writer.Replay(ctx, backups[0].Name, dst); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(dst.records, c.want) {
+				t.Errorf("records incorrect, got:%q want:%q", dst.records, c.want)
+			}
+		})
+	}
+}
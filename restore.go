@@ -0,0 +1,52 @@
+package rotate
+
+import (
+	"go.uber.org/multierr"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Restore decompress backupName to dstPath with the compression it was written with, gzip or
+// the recompression codec, a plain backup is copied. dstPath is replaced atomically.
+func (r *RotateWriter) Restore(backupName, dstPath string) (err error) {
+	rc, err := r.openBackup(backupName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Append(err, rc.Close())
+	}()
+	if err = os.MkdirAll(filepath.Dir(dstPath), defaultDirPerm); err != nil {
+		return err
+	}
+	tmp := dstPath + ".tmp"
+	fp, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, defaultFilePerm)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fp, rc)
+	if err = multierr.Combine(err, fp.Sync(), fp.Close()); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dstPath)
+}
+
+// RestoreAll restore every backup into dstDir under its uncompressed name relative to the log
+// directory and return the restored files in backup order
+func (r *RotateWriter) RestoreAll(dstDir string) ([]string, error) {
+	backups, err := r.Backups()
+	if err != nil {
+		return nil, err
+	}
+	restored := make([]string, 0, len(backups))
+	for _, b := range backups {
+		dst := filepath.Join(dstDir, filepath.FromSlash(r.relName(r.checkpointID(b.Name))))
+		if err := r.Restore(b.Name, dst); err != nil {
+			return restored, err
+		}
+		restored = append(restored, dst)
+	}
+	return restored, nil
+}
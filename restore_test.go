@@ -0,0 +1,58 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_RestoreAll(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "restore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithClock(&stepClock{now: time.Now()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for _, data := range []string{"first\n", "second\n"} {
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "restored")
+	restored, err := writer.RestoreAll(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored) != 2 {
+		t.Fatalf("restored incorrect, got:%v", restored)
+	}
+	for i, want := range []string{"first\n", "second\n"} {
+		if filepath.Ext(restored[i]) != ".log" {
+			t.Errorf("name incorrect, got:%s", restored[i])
+		}
+		got, err := ioutil.ReadFile(restored[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("content incorrect, got:%q want:%q", got, want)
+		}
+	}
+}
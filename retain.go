@@ -0,0 +1,54 @@
+package rotate
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// WithRetainPattern never remove backups matching glob by age or count, e.g. "*-KEEP*",
+// the glob is matched against the base name, or the full path if it contains a separator.
+// Pinned backups do not count toward WithMaxBackups.
+func WithRetainPattern(glob string) RotateOption {
+	return func(o *rotateOption) {
+		o.retainPattern = glob
+	}
+}
+
+// SetRetention change WithMaxBackups and WithMaxDays of a running writer,
+// the new limits apply from the next cleanup after a rotation
+func (r *RotateWriter) SetRetention(maxBackups, maxDays int64) {
+	r.retentionMu.Lock()
+	defer r.retentionMu.Unlock()
+	r.opt.maxBackups, r.opt.maxDays = maxBackups, maxDays
+}
+
+// retention return the current maxBackups and maxDays
+func (r *RotateWriter) retention() (int64, int64) {
+	r.retentionMu.RLock()
+	defer r.retentionMu.RUnlock()
+	return r.opt.maxBackups, r.opt.maxDays
+}
+
+// excludeRetained drop the pinned backups of files
+func (r *RotateWriter) excludeRetained(files []string) []string {
+	if len(r.opt.retainPattern) == 0 {
+		return files
+	}
+	kept := files[:0]
+	for _, file := range files {
+		if !r.retained(file) {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
+
+// retained
+func (r *RotateWriter) retained(file string) bool {
+	name := filepath.Base(file)
+	if strings.ContainsRune(r.opt.retainPattern, filepath.Separator) {
+		name = file
+	}
+	ok, _ := filepath.Match(r.opt.retainPattern, name)
+	return ok
+}
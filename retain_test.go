@@ -0,0 +1,79 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_WithRetainPattern(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "retain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+	old := time.Now().AddDate(0, 0, -10).Format(defaultTimeFormat)
+	pinned := mockBackupName(filename, old+"-KEEP")
+	outdated := mockBackupName(filename, old)
+	for _, name := range []string{pinned, outdated} {
+		if err := ioutil.WriteFile(name, []byte("test"), defaultFilePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(name, time.Now().AddDate(0, 0, -10), time.Now().AddDate(0, 0, -10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writer, err := NewRotateWriter(filename, WithMaxDays(7), WithMaxBackups(1), WithRetainPattern("*-KEEP*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	writer.removeOutdatedFiles()
+	writer.removeOverMaxFiles()
+	if _, err := os.Stat(pinned); err != nil {
+		t.Errorf("pinned backup should be kept: %v", err)
+	}
+	if _, err := os.Stat(outdated); !os.IsNotExist(err) {
+		t.Error("outdated backup should be removed")
+	}
+}
+
+func TestRotateWriter_SetRetention(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "retention")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithClock(&stepClock{now: time.Now()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	rotate := func() {
+		if _, err := writer.Write([]byte("test\n")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		rotate()
+	}
+	writer.SetRetention(1, 0)
+	rotate()
+	if backups, err := writer.Backups(); err != nil {
+		t.Fatal(err)
+	} else if len(backups) != 1 {
+		t.Errorf("backups incorrect, got:%+v", backups)
+	}
+}
@@ -0,0 +1,201 @@
+package rotate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go.uber.org/multierr"
+	"io"
+	"os"
+	"sync"
+)
+
+// ringHeaderSize is the fixed size of the head-pointer record at the start of a ring file
+const ringHeaderSize = 64
+
+var (
+	ErrRingSize    = errors.New("error: ring file has another size")
+	ErrRingCorrupt = errors.New("error: ring file header is corrupt")
+)
+
+// RingWriter write to a single preallocated file of fixed size, wrapping around once full. It never
+// rotates nor creates backups, for devices where bounded logging matters more than history. The file
+// start with a head-pointer record followed by size bytes of data, see ReadRing.
+type RingWriter struct {
+	mu      sync.Mutex
+	fp      *os.File
+	size    int64
+	head    int64 // offset in the data of the next write
+	wrapped bool  // the data was overwritten at least once
+	closed  bool
+}
+
+var _ io.WriteCloser = (*RingWriter)(nil)
+
+// NewRingWriter open the ring file filename holding size bytes of data, the file is preallocated
+// when created and writes resume at its head pointer otherwise
+func NewRingWriter(filename string, size int64) (*RingWriter, error) {
+	if len(filename) == 0 {
+		return nil, ErrFileNameIsEmpty
+	}
+	if size <= 0 {
+		return nil, ErrRingSize
+	}
+	fp, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|cloexecFlag, defaultFilePerm)
+	if err != nil {
+		return nil, err
+	}
+	w := &RingWriter{fp: fp, size: size}
+	if err = w.load(); err != nil {
+		return nil, multierr.Append(err, fp.Close())
+	}
+	return w, nil
+}
+
+// load read the head pointer of an existing ring or preallocate a new one
+func (w *RingWriter) load() error {
+	fi, err := w.fp.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() == 0 {
+		return w.preallocate()
+	}
+	size, head, wrapped, err := readRingHeader(w.fp)
+	if err != nil {
+		return err
+	}
+	if size != w.size {
+		return ErrRingSize
+	}
+	w.head, w.wrapped = head, wrapped
+	return nil
+}
+
+// preallocate write the whole file once so later writes never grow it
+func (w *RingWriter) preallocate() error {
+	zero := make([]byte, 32*1024)
+	for left := ringHeaderSize + w.size; left > 0; {
+		n := int64(len(zero))
+		if left < n {
+			n = left
+		}
+		if _, err := w.fp.Write(zero[:n]); err != nil {
+			return err
+		}
+		left -= n
+	}
+	return multierr.Append(w.writeHeader(), w.fp.Sync())
+}
+
+// Write copy p at the head, wrapping to the start of the data, then move the head pointer
+func (w *RingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, ErrLogFileClosed
+	}
+	if int64(len(p)) > w.size {
+		return 0, ErrDataOversize
+	}
+	first := p
+	if w.head+int64(len(p)) > w.size {
+		first = p[:w.size-w.head]
+	}
+	if _, err := w.fp.WriteAt(first, ringHeaderSize+w.head); err != nil {
+		return 0, err
+	}
+	if rest := p[len(first):]; len(rest) > 0 {
+		if _, err := w.fp.WriteAt(rest, ringHeaderSize); err != nil {
+			return 0, err
+		}
+	}
+	if w.head+int64(len(p)) >= w.size {
+		w.wrapped = true
+	}
+	w.head = (w.head + int64(len(p))) % w.size
+	// the data is written first, a crash in between only lose the record
+	if err := w.writeHeader(); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Sync commit the ring file to stable storage
+func (w *RingWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrLogFileClosed
+	}
+	return w.fp.Sync()
+}
+
+// Close
+func (w *RingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return multierr.Append(w.fp.Sync(), w.fp.Close())
+}
+
+// writeHeader
+func (w *RingWriter) writeHeader() error {
+	wrapped := 0
+	if w.wrapped {
+		wrapped = 1
+	}
+	header := fmt.Sprintf("#ring %d %d %d", w.size, w.head, wrapped)
+	buf := bytes.Repeat([]byte{' '}, ringHeaderSize)
+	copy(buf, header)
+	buf[ringHeaderSize-1] = '\n'
+	_, err := w.fp.WriteAt(buf, 0)
+	return err
+}
+
+// readRingHeader
+func readRingHeader(r io.ReaderAt) (size, head int64, wrapped bool, err error) {
+	buf := make([]byte, ringHeaderSize)
+	if _, err = r.ReadAt(buf, 0); err != nil {
+		return 0, 0, false, ErrRingCorrupt
+	}
+	var flag int
+	if _, err = fmt.Sscanf(string(buf), "#ring %d %d %d", &size, &head, &flag); err != nil {
+		return 0, 0, false, ErrRingCorrupt
+	}
+	if size <= 0 || head < 0 || head >= size {
+		return 0, 0, false, ErrRingCorrupt
+	}
+	return size, head, flag == 1, nil
+}
+
+// ReadRing return the data of the ring file filename oldest first. Once the ring wrapped the
+// oldest line is usually cut by the head, so it is dropped up to the first newline.
+func ReadRing(filename string) ([]byte, error) {
+	fp, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	size, head, wrapped, err := readRingHeader(fp)
+	if err != nil {
+		return nil, err
+	}
+	if !wrapped {
+		data := make([]byte, head)
+		_, err = fp.ReadAt(data, ringHeaderSize)
+		return data, err
+	}
+	data := make([]byte, size)
+	if _, err = fp.ReadAt(data, ringHeaderSize); err != nil {
+		return nil, err
+	}
+	ordered := append(data[head:len(data):len(data)], data[:head]...)
+	if i := bytes.IndexByte(ordered, '\n'); i >= 0 {
+		ordered = ordered[i+1:]
+	}
+	return ordered, nil
+}
@@ -0,0 +1,58 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRingWriter(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "ring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "app.ring")
+	writer, err := NewRingWriter(filename, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(filename); err != nil || fi.Size() != ringHeaderSize+16 {
+		t.Fatalf("preallocated size incorrect, got:%v %v", fi, err)
+	}
+	for _, line := range []string{"line 1\n", "line 2\n"} {
+		if _, err := writer.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if data, err := ReadRing(filename); err != nil || string(data) != "line 1\nline 2\n" {
+		t.Errorf("ring incorrect, got:%q %v", data, err)
+	}
+	if _, err := writer.Write(make([]byte, 17)); err != ErrDataOversize {
+		t.Errorf("oversize write incorrect, got:%v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// reopening resume at the head and wrap around
+	if _, err := NewRingWriter(filename, 32); err != ErrRingSize {
+		t.Errorf("size mismatch incorrect, got:%v", err)
+	}
+	writer, err = NewRingWriter(filename, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := writer.Write([]byte("line 3\n")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ReadRing(filename); err != nil || string(data) != "line 2\nline 3\n" {
+		t.Errorf("wrapped ring incorrect, got:%q %v", data, err)
+	}
+	if fi, err := os.Stat(filename); err != nil || fi.Size() != ringHeaderSize+16 {
+		t.Errorf("ring should not grow, got:%v %v", fi, err)
+	}
+}
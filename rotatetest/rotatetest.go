@@ -0,0 +1,137 @@
+// Package rotatetest help applications test their rotation configuration deterministically.
+package rotatetest
+
+import (
+	"context"
+	"github.com/AlfredAlan/rotate"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+const waitTimeout = 5 * time.Second
+
+type (
+	// Clock is a rotate.Clock moved only by Add and Set
+	Clock struct {
+		mu  sync.Mutex
+		now time.Time
+	}
+
+	// Harness run a writer in a temporary directory with a fake clock
+	Harness struct {
+		Dir      string
+		Filename string
+		Clock    *Clock
+		Writer   *rotate.RotateWriter
+		tb       testing.TB
+	}
+)
+
+var _ rotate.Clock = (*Clock)(nil)
+
+// NewClock start at now
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Add move the clock forward by d
+func (c *Clock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// New create a writer on app.log in a temporary directory, the writer is closed and the
+// directory removed when the test ends. options are applied after the fake clock.
+func New(tb testing.TB, options ...rotate.RotateOption) *Harness {
+	tb.Helper()
+	h := &Harness{
+		Dir:   tb.TempDir(),
+		Clock: NewClock(time.Now()),
+		tb:    tb,
+	}
+	h.Filename = filepath.Join(h.Dir, "app.log")
+	writer, err := rotate.NewRotateWriter(h.Filename, append([]rotate.RotateOption{rotate.WithClock(h.Clock)}, options...)...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	h.Writer = writer
+	tb.Cleanup(func() {
+		_ = writer.Close()
+	})
+	return h
+}
+
+// TriggerRotation rotate the active file, wait for compression, upload and retention and return
+// the backup name as Rotate does, the clock moves one second first so every backup gets a distinct name
+func (h *Harness) TriggerRotation() string {
+	h.tb.Helper()
+	h.Clock.Add(time.Second)
+	backup, err := h.Writer.Rotate()
+	if err != nil {
+		h.tb.Fatal(err)
+	}
+	Wait(h.tb, h.Writer)
+	return backup
+}
+
+// Wait block until the background tasks of w are done
+func Wait(tb testing.TB, w *rotate.RotateWriter) {
+	tb.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), waitTimeout)
+	defer cancel()
+	if err := w.Wait(ctx); err != nil {
+		tb.Fatalf("rotatetest: waiting for background tasks: %v", err)
+	}
+}
+
+// AssertBackupCount fail the test unless w has exactly n backups
+func AssertBackupCount(tb testing.TB, w *rotate.RotateWriter, n int) {
+	tb.Helper()
+	backups, err := w.Backups()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if len(backups) != n {
+		tb.Errorf("rotatetest: backup count incorrect, want:%d, got:%d %v", n, len(backups), names(backups))
+	}
+}
+
+// AssertCompressed fail the test if any backup of w is not compressed
+func AssertCompressed(tb testing.TB, w *rotate.RotateWriter) {
+	tb.Helper()
+	backups, err := w.Backups()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	for _, b := range backups {
+		if !b.Compressed {
+			tb.Errorf("rotatetest: backup %s is not compressed", b.Name)
+		}
+	}
+}
+
+// names
+func names(backups []rotate.BackupInfo) []string {
+	out := make([]string, 0, len(backups))
+	for _, b := range backups {
+		out = append(out, b.Name)
+	}
+	return out
+}
@@ -0,0 +1,32 @@
+package rotatetest
+
+import (
+	"github.com/AlfredAlan/rotate"
+	"testing"
+	"time"
+)
+
+func TestHarness(t *testing.T) {
+	h := New(t, rotate.WithGzip(true), rotate.WithMaxBackups(2))
+	for i := 0; i < 3; i++ {
+		if _, err := h.Writer.Write([]byte("test\n")); err != nil {
+			t.Fatal(err)
+		}
+		h.TriggerRotation()
+	}
+	AssertBackupCount(t, h.Writer, 2)
+	AssertCompressed(t, h.Writer)
+}
+
+func TestHarness_maxDays(t *testing.T) {
+	h := New(t, rotate.WithMaxDays(1))
+	h.TriggerRotation()
+	AssertBackupCount(t, h.Writer, 1)
+
+	// backups are named after the time the file was opened, both are outdated two days later
+	h.Clock.Add(48 * time.Hour)
+	h.TriggerRotation()
+	AssertBackupCount(t, h.Writer, 0)
+	h.TriggerRotation()
+	AssertBackupCount(t, h.Writer, 1)
+}
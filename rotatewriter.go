@@ -1,19 +1,22 @@
 package rotate
 
 import (
-	"compress/gzip"
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"go.uber.org/atomic"
 	"go.uber.org/multierr"
 	"io"
+	"net"
 	"os"
+	"os/signal"
 	"path"
 	"path/filepath"
-	"sort"
+	"regexp"
+	"runtime"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 )
 
@@ -25,31 +28,186 @@ var (
 
 type (
 	RotateWriter struct {
-		filename   string // log path and file name
-		prefix     string // log prefix include base path
-		ext        string // log extension
-		backupName string // log backup name
-		size       int64  // log current size
-		opt        *rotateOption
-		err        error
-		postCh     chan string
-		postDone   chan struct{}
-		fp         *os.File
-		mu         sync.Mutex
-		closeOnce  sync.Once
-		done       atomic.Bool
+		filename        string    // log path and file name, filename prefix and ext are guarded by nameMu
+		prefix          string    // log prefix include base path
+		ext             string    // log extension
+		backupName      string    // log backup name
+		size            int64     // log current size
+		partial         bool      // the active file does not end with a newline
+		file            fileStats // what was written to the active file, tracked for sidecars
+		sinceSpaceCheck int64     // bytes written since free space was last checked
+		buffer          bufferState
+		spool           spoolState
+		writeSeq        uint64 // count of writes to the active file
+		openedAt        time.Time
+		rotatedAt       time.Time
+		opt             *rotateOption
+		errMu           sync.Mutex // guards err, kept and keptDropped, so the worker never takes mu
+		err             error
+		kept            []error // background errors reported by Close, at most maxKeptErrors
+		keptDropped     int
+		failures        int    // consecutive I/O failures, see WithFailoverDir
+		primary         string // the primary file while writing to the failover directory
+		mirror          *RotateWriter
+		controlLn       net.Listener // the control socket, closed by Close
+		metricsMu       sync.Mutex
+		reported        Stats           // the stats of the last metrics report
+		traceCtx        context.Context // the context of the write in progress, see WithTracer
+		postCh          chan backup
+		postDone        chan struct{}
+		stats           counters
+		filter          filterState
+		chain           WriteFunc
+		subMu           sync.Mutex
+		subs            map[int]chan RotateEvent
+		listeners       map[int]chan Event
+		hashChain       chainState
+		dailyMu         sync.Mutex // serialize appends to the daily gzip backup
+		nextSubID       int
+		failedMu        sync.Mutex
+		failed          []backup  // backups waiting for upload retry, guarded by failedMu
+		daily           backup    // daily gzip backup collecting the current day, only touched by afterRotate
+		deferred        []backup  // backups waiting for the maintenance window, only touched by afterRotate
+		delayed         []backup  // plain backups waiting for WithCompressAfter, only touched by afterRotate
+		rotateNext      bool      // RotateAfterNext was called, protected by mu
+		nextRotation    *Rotation // claimed by the next rotation, protected by mu
+		rotationsMu     sync.Mutex
+		rotations       map[string]*Rotation // pending Rotation by plain backup name
+		tail            *tailRing            // see WithMemoryTail
+		recovered       []string             // sources of interrupted compressions, only touched by afterRotate
+		graced          []backup             // backups waiting for WithDeleteGrace, only touched by afterRotate
+		graceDue        time.Time            // when the first graced backup is due, only touched by afterRotate
+		graceTimer      *time.Timer          // fire when the first graced backup is due, only touched by afterRotate
+		fp              *os.File
+		mu              sync.Mutex
+		closeOnce       sync.Once
+		done            atomic.Bool
+		spillMu         sync.Mutex
+		spills          []backup      // backups left unqueued by OverflowSpill, protected by spillMu
+		cleaning        atomic.Bool   // a cleanup pass is running
+		removals        removalQueue  // see WithCleanupRate
+		backupBytes     atomic.Int64  // size of the backups on disk, maintained when a quota is set
+		pruneCh         chan struct{} // a write went over the quota
+		inflightMu      sync.Mutex
+		inflight        map[string]bool // plain names of the backups queued or processed, protected by inflightMu
+		nameMu          sync.RWMutex
+		taskMu          sync.Mutex
+		indexMu         sync.Mutex
+		retentionMu     sync.RWMutex  // guards maxBackups and maxDays changed by SetRetention
+		pending         int           // queued background tasks not finished yet
+		idle            chan struct{} // closed when pending drop to zero
+		taskCond        *sync.Cond    // signaled whenever a background task finishes
+		syncMu          sync.Mutex    // one group commit flush at a time
+		flushing        sync.WaitGroup
+		syncedSeq       atomic.Uint64 // writeSeq covered by the last flush
 	}
 
 	rotateOption struct {
-		delimiter  string
-		timeFormat string
-		gzip       bool
-		localTime  bool
-		maxDays    int64
-		maxSize    int64
-		maxBackups int64
+		delimiter         string
+		timeFormat        string
+		dateIndex         bool
+		gzip              bool
+		syncCompress      bool
+		localTime         bool
+		maxDays           int64
+		maxSize           int64
+		minRotateInterval time.Duration
+		window            bool
+		windowStart       time.Duration
+		windowEnd         time.Duration
+		maxBackups        int64
+		uploader          Uploader
+		manifest          bool
+		manifestName      string
+		upManifest        bool
+		deleteAfterUpload bool
+		onUploadError     func(filename string, err error)
+		lineTime          func(line []byte) (time.Time, bool)
+		rotateInterval    time.Duration
+		jsonLines         bool
+		validateJSON      bool
+		header            func(w io.Writer) error
+		footer            func(w io.Writer) error
+		middlewares       []func(o *rotateOption) Middleware
+		framing           Framing
+		ensureNewline     bool
+		maxMessageSize    int64
+		lumberjack        bool
+		keepOriginal      bool
+		queueSize         int
+		compressPool      *CompressPool
+		rotateMarker      []byte
+		registry          bool
+		labels            map[string]string
+		memoryTail        int
+		compressLevel     *int
+		compressBuffer    int
+		maxPending        int
+		cleanupBatch      int
+		cleanupInterval   time.Duration
+		cleanupWorkers    int
+		syncSignals       []os.Signal
+		controlSocket     string
+		syncBeforeRotate  bool
+		dataSync          bool
+		syncEveryWrite    bool
+		dirSync           *bool
+		bufferMax         int
+		bufferDelay       time.Duration
+		timeouts          TaskTimeouts
+		failoverDir       string
+		failoverProbe     time.Duration
+		mirror            string
+		spoolSize         int
+		nice              int
+		ioPriority        IOPriority
+		metrics           MetricsSink
+		metricsInterval   time.Duration
+		tracer            Tracer
+		compactPeriod     time.Duration
+		dailyGzip         bool
+		compressAfter     time.Duration
+		plainBackups      int
+		audit             bool
+		auditKey          []byte
+		symlink           string
+		deleteGrace       time.Duration
+		clock             Clock
+		quota             int64
+		minFreeBytes      int64
+		minFreePercent    float64
+		diskFull          DiskFullPolicy
+		recompress        Codec
+		recompressDays    int64
+		truncateOnOpen    bool
+		backupPerm        os.FileMode
+		backupAttr        BackupAttr
+		retainPattern     string
+		sidecar           bool
+		index             bool
+		indexName         string
+		overflow          OverflowPolicy
+		backupGlob        string
+		backupRegexp      *regexp.Regexp
+		ext               *string
+		dedup             bool
+		dedupFlush        time.Duration
+		sampleFirst       int
+		sampleThereafter  int
+		sampleTick        time.Duration
 	}
 	RotateOption func(*rotateOption)
+
+	// backup describes a rotated file waiting for post processing
+	backup struct {
+		name       string
+		start      time.Time
+		end        time.Time
+		file       fileStats
+		compressed bool   // compressed by rotate with WithSyncCompress
+		plain      string // the name before WithSyncCompress compressed it
+		merged     bool   // merged by Compact, already indexed and protected
+	}
 )
 
 var _ io.WriteCloser = (*RotateWriter)(nil)
@@ -61,7 +219,6 @@ func NewRotateWriter(filename string, options ...RotateOption) (*RotateWriter, e
 	}
 	r := &RotateWriter{
 		filename: filename,
-		postCh:   make(chan string, 100), // no block channel
 		postDone: make(chan struct{}),
 	}
 	opt := &rotateOption{
@@ -72,16 +229,82 @@ func NewRotateWriter(filename string, options ...RotateOption) (*RotateWriter, e
 		maxBackups: defaultMaxBackups,
 		localTime:  true,
 		gzip:       false,
+		queueSize:  defaultQueueSize,
+		clock:      systemClock{},
 	}
 	for _, fn := range options {
 		fn(opt)
 	}
+	if opt.deleteAfterUpload && opt.uploader == nil {
+		return nil, ErrNoUploader
+	}
 	r.opt = opt
+	r.labelSink()
+	r.tail = newTailRing(opt.memoryTail)
+	r.postCh = make(chan backup, opt.queueSize)
+	r.taskCond = sync.NewCond(&r.taskMu)
+	r.chain = r.buildChain()
+	r.removals.wake = make(chan struct{}, 1)
+	r.pruneCh = make(chan struct{}, 1)
+	if opt.truncateOnOpen {
+		if err := os.Truncate(filename, 0); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	var control net.Listener
+	if len(opt.controlSocket) > 0 {
+		var err error
+		if control, err = r.listenControl(); err != nil {
+			return nil, err
+		}
+	}
 	if err := r.init(); err != nil {
+		if control != nil {
+			err = multierr.Append(err, control.Close())
+		}
 		return nil, err
 	}
+	r.enforceQuota()
+	r.recoverBackups()
+	if len(r.recovered) > 0 {
+		r.addTask()
+	}
+	r.loadDelayed()
+	if r.opt.overflow == OverflowSpill {
+		r.loadSpilled()
+		r.addTask()
+	}
+	r.loadFailed()
 	// handle other thing like compress and remove outdated files
 	go r.afterRotate()
+	if r.opt.cleanupBatch > 0 {
+		go r.removeLoop()
+	}
+	if r.opt.rotateInterval > 0 {
+		go r.rotateOnInterval()
+	}
+	if r.opt.filterInterval() > 0 {
+		go r.flushFilters()
+	}
+	if r.opt.bufferMax > 0 && r.opt.bufferDelay > 0 {
+		go r.flushBufferOnInterval()
+	}
+	if len(r.opt.syncSignals) > 0 {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, r.opt.syncSignals...)
+		go r.syncOnSignal(sigCh)
+	}
+	if control != nil {
+		r.controlLn = control
+		go r.serveControl(control)
+	}
+	if r.opt.metrics != nil {
+		go r.reportMetrics()
+	}
+	if len(opt.mirror) > 0 {
+		r.openMirror(options)
+	}
+	r.register()
 	return r, nil
 }
 
@@ -92,6 +315,22 @@ func WithGzip(gzip bool) RotateOption {
 	}
 }
 
+// WithSyncCompress compress the backup inside the rotation instead of in the background, so
+// the .gz exists once the Write or Rotate that rotated returns
+func WithSyncCompress(sync bool) RotateOption {
+	return func(o *rotateOption) {
+		o.syncCompress = sync
+	}
+}
+
+// WithKeepOriginal keep the uncompressed backup next to the compressed one, for tailers still reading it,
+// both count as one backup for retention
+func WithKeepOriginal(keep bool) RotateOption {
+	return func(o *rotateOption) {
+		o.keepOriginal = keep
+	}
+}
+
 // WithMaxDays
 func WithMaxDays(days int64) RotateOption {
 	return func(o *rotateOption) {
@@ -99,7 +338,9 @@ func WithMaxDays(days int64) RotateOption {
 	}
 }
 
-// WithMaxSize
+// WithMaxSize set the size in megabytes the active file is rotated at, default 128. An existing
+// file opened for appending counts with its current size, so a restart does not let it grow
+// past the limit.
 func WithMaxSize(max int64) RotateOption {
 	return func(o *rotateOption) {
 		if max <= 0 {
@@ -110,6 +351,23 @@ func WithMaxSize(max int64) RotateOption {
 	}
 }
 
+// WithMinRotateInterval let the file grow past the size limit until d elapsed since the last
+// rotation, so a burst can't trigger a rotation per write. Rotate and interval rotations are not limited.
+func WithMinRotateInterval(d time.Duration) RotateOption {
+	return func(o *rotateOption) {
+		o.minRotateInterval = d
+	}
+}
+
+// WithMaxMessageSize accept messages up to max bytes independently of the file size limit,
+// a message larger than the space left rotates the file first, a message larger than max is
+// truncated with a marker instead of being rejected
+func WithMaxMessageSize(max int64) RotateOption {
+	return func(o *rotateOption) {
+		o.maxMessageSize = max
+	}
+}
+
 // WithLocalTime
 func WithLocalTime(local bool) RotateOption {
 	return func(o *rotateOption) {
@@ -124,6 +382,13 @@ func WithMaxBackups(max int64) RotateOption {
 	}
 }
 
+// WithEnsureNewline append a newline to records not ending with one, the added byte count toward the size limit
+func WithEnsureNewline(ensure bool) RotateOption {
+	return func(o *rotateOption) {
+		o.ensureNewline = ensure
+	}
+}
+
 // WithDelimiter
 func WithDelimiter(s string) RotateOption {
 	return func(o *rotateOption) {
@@ -135,7 +400,15 @@ func WithDelimiter(s string) RotateOption {
 	}
 }
 
-// WithTimeFormat
+// WithTruncateOnOpen empty an existing file when the writer is created instead of appending to it
+func WithTruncateOnOpen(truncate bool) RotateOption {
+	return func(o *rotateOption) {
+		o.truncateOnOpen = truncate
+	}
+}
+
+// WithTimeFormat set the Go layout of backup timestamps, it may contain TokenISOYear,
+// TokenISOWeek and TokenQuarter, a "/" puts backups in sub directories
 func WithTimeFormat(format string) RotateOption {
 	return func(o *rotateOption) {
 		if len(format) == 0 {
@@ -148,23 +421,117 @@ func WithTimeFormat(format string) RotateOption {
 
 // afterRotate
 func (r *RotateWriter) afterRotate() {
+	r.lowerPriority()
+	r.graceTimer = time.NewTimer(time.Hour)
+	r.graceTimer.Stop()
+	defer r.graceTimer.Stop()
+	if len(r.recovered) > 0 {
+		r.processRecovered()
+		r.doneTask()
+	}
+	var (
+		timer  *time.Timer
+		window <-chan time.Time // nil without maintenance window
+		delay  = time.NewTimer(0)
+	)
+	if r.opt.window {
+		timer = time.NewTimer(r.untilWindow(r.now()))
+		defer timer.Stop()
+		window = timer.C
+	}
+	defer delay.Stop()
+	if r.opt.overflow == OverflowSpill {
+		// the backups spilled before a restart
+		r.processSpilled(delay)
+		r.doneTask()
+	}
 	for {
 		select {
-		case filename := <-r.postCh:
-			r.compressFile(filename)
-			r.removeOutdatedFiles()
-			r.removeOverMaxFiles()
+		case b := <-r.postCh:
+			r.handle(b, delay)
+			// the queue has room again
+			r.processSpilled(delay)
+			r.doneTask()
+		case <-window:
+			r.maintain()
+			timer.Reset(r.nextWindow(r.now()))
+		case <-delay.C:
+			r.scheduleDelayed(delay)
+		case <-r.pruneCh:
+			r.enforceQuota()
+		case <-r.graceTimer.C:
+			r.releaseGraced()
 		case <-r.postDone:
 			return
 		}
 	}
 }
 
+// handle keep b plain for WithCompressAfter, defer it to the maintenance window or process it,
+// only called by afterRotate
+func (r *RotateWriter) handle(b backup, delay *time.Timer) {
+	if r.delayBackup(b) {
+		r.scheduleDelayed(delay)
+		return
+	}
+	if !r.deferBackup(b) {
+		r.process(b)
+	}
+}
+
+// process compress, publish and upload a backup then apply retention
+func (r *RotateWriter) process(b backup) {
+	if b.merged {
+		r.processMerged(b)
+		return
+	}
+	var failed error // compression or upload error of b, reported to its Rotation
+	plain := b.name
+	if b.compressed {
+		plain = b.plain
+	} else {
+		if r.opt.gzip && !r.opt.keepOriginal && r.parkGrace(b) {
+			return
+		}
+		b.name, failed = r.compressFile(b.name)
+	}
+	if err := r.writeSidecar(b); err != nil {
+		r.setErr(err)
+	}
+	shipped := b
+	if r.opt.dailyGzip && b.name != plain {
+		// one index entry and one upload per daily backup, not per appended member
+		shipped = r.trackDaily(plain, b)
+	} else if err := r.indexAdd(b.name, b.start, b.end); err != nil {
+		r.setErr(err)
+	}
+	if err := r.protectBackup(b.name); err != nil {
+		r.setErr(err)
+	}
+	if r.opt.gzip && r.opt.keepOriginal && plain != b.name {
+		if err := r.protectBackup(plain); err != nil {
+			r.setErr(err)
+		}
+	}
+	r.publish(RotateEvent{Backup: b.name, Start: b.start, End: b.end})
+	if len(shipped.name) > 0 {
+		if err := r.uploadFile(context.Background(), shipped); err != nil && shipped.name == b.name {
+			failed = multierr.Append(failed, err)
+		}
+	}
+	r.finishRotation(plain, b.name, failed)
+	r.doneInflight(plain)
+	r.cleanup()
+	r.recompressOld()
+}
+
 // init
 func (r *RotateWriter) init() error {
-	r.ext = filepath.Ext(r.filename)
-	r.prefix = r.filename[:len(r.filename)-len(r.ext)]
+	r.nameMu.Lock()
+	r.prefix, r.ext = splitName(r.filename, r.opt.ext)
+	r.nameMu.Unlock()
 	r.backupName = r.backupFileName()
+	r.openedAt = r.now()
 	// create writer if exist filename or open it
 	if _, err := os.Stat(r.filename); err != nil {
 		basePath := path.Dir(r.filename)
@@ -173,257 +540,727 @@ func (r *RotateWriter) init() error {
 				return err
 			}
 		}
-		if r.fp, err = os.Create(r.filename); err != nil {
+		if r.fp, err = createFile(r.filename); err != nil {
 			return err
 		}
-	} else if r.fp, err = os.OpenFile(r.filename, os.O_APPEND|os.O_WRONLY, defaultFilePerm); err != nil {
+		if err = r.syncDirs(r.filename); err != nil {
+			return err
+		}
+	} else if r.fp, err = appendFile(r.filename); err != nil {
 		return err
 	}
-	closeOnExec(r.fp)
-	return nil
+	if err := r.linkActive(); err != nil {
+		return err
+	}
+	fi, err := r.fp.Stat()
+	if err != nil {
+		return err
+	}
+	// appended data count toward the size limit
+	r.size = fi.Size()
+	r.noteSize()
+	if r.size > 0 {
+		return r.restoreChain()
+	}
+	return r.writeHeader()
 }
 
 // backupFileName return backup file name, default layout is prefix-2006-01-02T15:04:05.000.ext
 func (r *RotateWriter) backupFileName() string {
+	if r.opt.dateIndex {
+		return r.indexedName(r.prefix+r.opt.delimiter+nowDate(r.now(), r.opt.timeFormat, r.opt.localTime), r.ext)
+	}
 	return fmt.Sprintf(
 		"%s%s%s%s",
 		r.prefix,
 		r.opt.delimiter,
-		nowDate(r.opt.timeFormat, r.opt.localTime),
+		nowDate(r.now(), r.opt.timeFormat, r.opt.localTime),
 		r.ext,
 	)
 }
 
+// backupPattern return the glob pattern of backups, default layout is prefix-*.ext or prefix-*.ext.gz
+func (r *RotateWriter) backupPattern(compressed bool) string {
+	_, prefix, ext := r.paths()
+	prefix, ext = globEscape(prefix+r.opt.delimiter), globEscape(ext)
+	date := dateGlob(r.opt.timeFormat)
+	if r.opt.dateIndex {
+		date += ".*"
+	}
+	if compressed {
+		return fmt.Sprintf("%s%s%s.gz", prefix, date, ext)
+	}
+	return fmt.Sprintf("%s%s%s", prefix, date, ext)
+}
+
+// globEscape escape the glob metacharacters of s
+func globEscape(s string) string {
+	var buf strings.Builder
+	for _, c := range s {
+		switch {
+		case c == '*' || c == '?' || c == '[':
+			buf.WriteByte('[')
+			buf.WriteRune(c)
+			buf.WriteByte(']')
+		case c == '\\' && runtime.GOOS != "windows":
+			buf.WriteString(`\\`)
+		default:
+			buf.WriteRune(c)
+		}
+	}
+	return buf.String()
+}
+
 // listFiles find outdated files by log layout pattern
 func (r *RotateWriter) listFiles() ([]string, error) {
-	var pattern string
-	if r.opt.gzip {
-		pattern = fmt.Sprintf("%s%s*%s.gz", r.prefix, r.opt.delimiter, r.ext)
-	} else {
-		pattern = fmt.Sprintf("%s%s*%s", r.prefix, r.opt.delimiter, r.ext)
+	files, err := r.candidateFiles()
+	if err != nil {
+		return []string{}, err
 	}
-	files, err := filepath.Glob(pattern)
+	return r.excludeRetained(files), nil
+}
+
+// candidateFiles return the backups retention may consider
+func (r *RotateWriter) candidateFiles() ([]string, error) {
+	if r.customBackupPattern() {
+		return r.matchBackups()
+	}
+	files, err := filepath.Glob(r.backupPattern(r.opt.gzip))
 	if err != nil {
 		return []string{}, err
 	}
+	if r.opt.gzip && r.opt.keepOriginal {
+		if files, err = r.allBackupFiles(); err != nil {
+			return []string{}, err
+		}
+	} else if _, _, ext := r.paths(); !r.opt.gzip && len(ext) == 0 {
+		// prefix-* also match compressed backups
+		files = r.excludeCompressed(files)
+	} else if r.opt.gzip {
+		if files, err = r.appendRecompressed(files); err != nil {
+			return []string{}, err
+		}
+	}
+	if r.opt.lumberjack {
+		return r.appendLumberjackFiles(files)
+	}
 	return files, nil
 }
 
 // Write
 func (r *RotateWriter) Write(data []byte) (int, error) {
-	r.mu.Lock()
+	return r.WriteContext(context.Background(), data)
+}
+
+// WriteContext write data like Write but give up waiting for the lock or for WithMaxPendingBackups
+// when ctx is done, returning ctx.Err(). Once data is being written it is not interrupted.
+func (r *RotateWriter) WriteContext(ctx context.Context, data []byte) (int, error) {
+	n, err := r.writeContext(ctx, data)
+	if r.mirror == nil {
+		return n, err
+	}
+	return r.writeMirror(ctx, data, n, err)
+}
+
+// writeContext write data to the active file
+func (r *RotateWriter) writeContext(ctx context.Context, data []byte) (int, error) {
+	n, seq, err := r.appendData(ctx, data)
+	if err != nil || !r.opt.syncEveryWrite {
+		return n, err
+	}
+	return n, r.commit(seq)
+}
+
+// appendData write data under the lock and return the write sequence it reached
+func (r *RotateWriter) appendData(ctx context.Context, data []byte) (int, uint64, error) {
+	if err := r.waitForBackups(ctx); err != nil {
+		r.stats.dropped.Inc()
+		return 0, 0, err
+	}
+	if err := r.lockContext(ctx); err != nil {
+		r.stats.dropped.Inc()
+		return 0, 0, err
+	}
 	defer r.mu.Unlock()
 
 	if r.done.Load() {
-		return 0, ErrLogFileClosed
+		r.stats.dropped.Inc()
+		return 0, r.writeSeq, ErrLogFileClosed
+	}
+	if r.opt.validateJSON {
+		if err := validateJSONLines(data); err != nil {
+			r.stats.dropped.Inc()
+			return 0, r.writeSeq, err
+		}
 	}
 	size := len(data)
-	if int64(size) > r.opt.maxSize {
-		return 0, ErrDataOversize
+	if suppressed, err := r.suppress(data); err != nil {
+		r.stats.dropped.Inc()
+		r.stats.lastErr.Store(err)
+		return 0, r.writeSeq, err
+	} else if suppressed {
+		return size, r.writeSeq, nil
 	}
-	if r.err != nil {
-		err := r.err
-		r.err = nil
-		return 0, err
+	if err := r.takeErr(); err != nil {
+		r.stats.dropped.Inc()
+		return 0, r.writeSeq, err
 	}
 
+	r.traceCtx = ctx
+	err := r.chainSpooled(data)
+	r.traceCtx = nil
+	r.noteResult(err)
+	if err != nil && r.opt.spoolSize > 0 && ioFailure(err) {
+		r.stats.lastErr.Store(err)
+		r.spoolRecord(data)
+		return size, r.writeSeq, nil
+	}
+	if err != nil {
+		r.stats.dropped.Inc()
+		r.stats.lastErr.Store(err)
+		return 0, r.writeSeq, err
+	}
+	r.stats.writes.Inc()
+	return size, r.writeSeq, nil
+}
+
+// maxRecord return the size limit of one record, readers of the backups size their buffers with it
+func (r *RotateWriter) maxRecord() int64 {
+	if r.opt.maxMessageSize > 0 {
+		return r.opt.maxMessageSize
+	}
+	return r.opt.maxSize
+}
+
+// writeRecord is the end of the middleware chain
+func (r *RotateWriter) writeRecord(data []byte) error {
+	record := data
+	if r.opt.ensureNewline && len(data) > 0 && data[len(data)-1] != '\n' {
+		data = append(data[:len(data):len(data)], '\n')
+	}
+	limit := r.maxRecord()
+	if r.opt.maxMessageSize > 0 {
+		data = truncateRecord(data, limit)
+	}
+	line := data
+	if r.opt.framing != FramingNone {
+		// one write per frame, so rotation only happens between frames
+		data = appendFrame(nil, r.opt.framing, data)
+	}
+	if int64(len(data)) > limit+frameOverhead(r.opt.framing) {
+		return ErrDataOversize
+	}
 	if err := r.write(data); err != nil {
-		return 0, err
+		return err
+	}
+	if r.tail != nil {
+		r.tail.add(line)
 	}
-	return size, nil
+	r.stats.bytes.Add(int64(len(data)))
+	r.rotateAfter(record)
+	return nil
+}
+
+// Rotate close the active file, rename it to a backup and open a new one. It return the name of
+// the backup, already compressed with WithSyncCompress, or empty if the active file was missing.
+// With background compression the compressed name is published by CompressCompleted.
+func (r *RotateWriter) Rotate() (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rotateLocked()
 }
 
-// Close
+// rotateLocked is Rotate with the lock held
+func (r *RotateWriter) rotateLocked() (string, error) {
+	if r.done.Load() {
+		return "", ErrLogFileClosed
+	}
+	backup, err := r.rotate()
+	r.noteResult(err)
+	if r.mirror != nil {
+		if _, mirrorErr := r.mirror.Rotate(); mirrorErr != nil {
+			r.mirrorFailedLocked(mirrorErr)
+		}
+	}
+	return backup, err
+}
+
+// Close stop the writer without waiting for pending background tasks, see Shutdown, the error
+// include the background errors of the writer lifetime
 func (r *RotateWriter) Close() (err error) {
 	r.closeOnce.Do(func() {
 		r.mu.Lock()
 		defer r.mu.Unlock()
 		r.done.Store(true)
 		close(r.postDone)
-		if err = r.fp.Sync(); err != nil {
+		r.wakeWriters()
+		r.closeSubscribers()
+		r.finishRotations(ErrLogFileClosed)
+		// background errors first, then the spool and the pending filter summaries
+		err = r.keptErrs()
+		if r.spool.spooling() {
+			err = multierr.Append(err, r.replaySpool())
+		}
+		err = multierr.Combine(err, r.flushRepeated(), r.flushSuppressed())
+		if syncErr := r.syncFile(); syncErr != nil {
+			err = multierr.Append(err, syncErr)
 			return
 		}
-		err = r.fp.Close()
+		r.waitFlush()
+		err = multierr.Append(err, r.fp.Close())
 	})
+	if r.controlLn != nil {
+		// closed before Close returns so the socket can be taken over right away
+		_ = r.controlLn.Close()
+	}
+	if r.mirror != nil {
+		err = multierr.Append(err, r.mirror.Close())
+	}
+	r.flushMetrics()
+	r.unregister()
 	return err
 }
 
+// Sync commit the active file to stable storage
+func (r *RotateWriter) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done.Load() {
+		return ErrLogFileClosed
+	}
+	if r.spool.spooling() {
+		if err := r.replaySpool(); err != nil {
+			return err
+		}
+	}
+	if r.mirror != nil {
+		if mirrorErr := r.mirror.Sync(); mirrorErr != nil {
+			r.mirrorFailedLocked(mirrorErr)
+		}
+	}
+	return r.syncFile()
+}
+
 // write
 func (r *RotateWriter) write(data []byte) error {
 	size := int64(len(data))
-	if (r.size + size) > r.opt.maxSize {
-		if err := r.rotate(); err != nil {
+	if err := r.checkQuota(size); err != nil {
+		return err
+	}
+	rotating := r.size > 0 && (r.size+size) > r.opt.maxSize && r.rotateAllowed()
+	if err := r.checkFreeSpace(size, rotating); err != nil {
+		return err
+	}
+	// a message larger than maxSize goes alone into a fresh file
+	if rotating {
+		if r.opt.jsonLines && r.partial {
+			// finish the pending record before rotating
+			i := bytes.IndexByte(data, '\n')
+			if i < 0 || i == len(data)-1 {
+				return r.writeFile(data)
+			}
+			if err := r.writeFile(data[:i+1]); err != nil {
+				return err
+			}
+			data = data[i+1:]
+		}
+		if _, err := r.rotate(); err != nil {
 			return err
 		}
-		r.size = 0
 	}
-	if r.fp != nil {
-		if _, err := r.fp.Write(data); err != nil {
+	return r.writeFile(data)
+}
+
+// writeFile
+func (r *RotateWriter) writeFile(data []byte) error {
+	if r.fp == nil || len(data) == 0 {
+		return nil
+	}
+	if r.opt.bufferMax > 0 {
+		if err := r.bufferWrite(data); err != nil {
 			return err
 		}
-		r.size += size
+	} else if _, err := r.fp.Write(data); err != nil {
+		return err
+	}
+	r.size += int64(len(data))
+	r.noteSize()
+	r.writeSeq++
+	r.partial = data[len(data)-1] != '\n'
+	if r.opt.audit && !r.hashChain.raw {
+		r.hashChain.add(r.opt.auditKey, data)
+	}
+	if r.opt.sidecar {
+		r.file.track(data, r.now())
 	}
 	return nil
 }
 
-// rotate
-func (r *RotateWriter) rotate() error {
+// lockContext take the lock unless ctx is done first
+func (r *RotateWriter) lockContext(ctx context.Context) error {
+	if ctx.Done() == nil {
+		r.mu.Lock()
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	locked := make(chan struct{})
+	go func() {
+		r.mu.Lock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		return nil
+	case <-ctx.Done():
+		// release the lock once the pending Lock gets it
+		go func() {
+			<-locked
+			r.mu.Unlock()
+		}()
+		return ctx.Err()
+	}
+}
+
+// rotateAllowed report whether a size rotation may happen now according to WithMinRotateInterval
+func (r *RotateWriter) rotateAllowed() bool {
+	if r.opt.minRotateInterval <= 0 || r.rotatedAt.IsZero() {
+		return true
+	}
+	return r.now().Sub(r.rotatedAt) >= r.opt.minRotateInterval
+}
+
+// rotate return the name of the backup, compressed with WithSyncCompress, empty if there was nothing to back up
+func (r *RotateWriter) rotate() (_ string, err error) {
+	start := time.Now()
+	defer r.timing("rotate", start)
+	r.emit(RotateStarted{File: r.filename, Size: r.size, Time: r.now()})
+	ctx, span := r.startSpan(r.writeCtx(), "rotate")
+	span.SetAttribute("rotate.file", r.filename)
+	span.SetAttribute("rotate.size", r.size)
+	defer func() {
+		span.End(err)
+	}()
 	if r.fp != nil {
+		if err := r.writeFooter(); err != nil {
+			return "", err
+		}
+		if err := r.flushBuffer(); err != nil {
+			return "", err
+		}
+		if r.opt.syncBeforeRotate || r.opt.syncEveryWrite {
+			if err := r.syncFile(); err != nil {
+				return "", err
+			}
+		}
+		r.waitFlush()
 		if err := r.fp.Close(); err != nil {
-			return err
+			return "", err
 		}
 		r.fp = nil
 	}
 
-	_, err := os.Stat(r.filename)
-	if err == nil && len(r.backupName) > 0 {
-		backupName := r.backupName
-		if err = os.Rename(r.filename, backupName); err != nil {
-			return err
+	now := r.now()
+	_, err = os.Stat(r.filename)
+	if err != nil || len(r.backupName) == 0 {
+		// nothing to back up
+		if r.fp, err = createFile(r.filename); err != nil {
+			return "", err
+		}
+		if err = r.syncDirs(r.filename); err != nil {
+			r.setErr(err)
+		}
+		r.startFile(now)
+		return "", r.writeHeader()
+	}
+
+	// move the active file aside first, the backup name is only published once the new
+	// active file exists, any failure puts the old file back in place
+	backupName := r.backupName
+	if err = os.MkdirAll(filepath.Dir(backupName), defaultDirPerm); err != nil {
+		return "", multierr.Append(err, r.reopen())
+	}
+	tmpName := r.filename + rotatingSuffix
+	if err = os.Rename(r.filename, tmpName); err != nil {
+		return "", multierr.Append(err, r.reopen())
+	}
+	fp, err := createFile(r.filename)
+	if err == nil {
+		if err = os.Rename(tmpName, backupName); err != nil {
+			err = multierr.Append(err, fp.Close())
+		}
+	}
+	if err != nil {
+		if rollbackErr := os.Rename(tmpName, r.filename); rollbackErr != nil {
+			return "", multierr.Append(err, rollbackErr)
 		}
-		// send backupName to compress and remove old logs
-		r.postCh <- backupName
+		return "", multierr.Append(err, r.reopen())
 	}
+
+	r.fp = fp
+	span.SetAttribute("rotate.backup", backupName)
+	if err = r.chmodBackup(backupName); err != nil {
+		r.setErr(err)
+	}
+	if err = r.syncDirs(r.filename, backupName); err != nil {
+		r.setErr(err)
+	}
+	r.stats.rotations.Inc()
+	r.backupBytes.Add(r.size)
+	r.rotatedAt = now
+	// send backupName to compress and remove old logs
+	r.file.bytes = r.size
+	b := backup{name: backupName, start: r.openedAt, end: now, file: r.file}
+	r.trackRotation(backupName)
+	if r.opt.syncCompress && r.opt.gzip {
+		if b.name, err = r.compressBackup(ctx, backupName); err != nil {
+			r.setErr(err)
+		}
+		b.compressed, b.plain = b.name != backupName, backupName
+	}
+	r.emit(RotateCompleted{Backup: backupName, Start: r.openedAt, End: now, Duration: time.Since(start)})
+	r.enqueue(b)
+	r.startFile(now)
+	return b.name, r.writeHeader()
+}
+
+// startFile reset the state of a new active file
+func (r *RotateWriter) startFile(now time.Time) {
 	//save next backup name
 	r.backupName = r.backupFileName()
-	if r.fp, err = os.Create(r.filename); err == nil {
-		closeOnExec(r.fp)
-	}
+	r.openedAt = now
+	r.size = 0
+	r.noteSize()
+	r.partial = false
+	r.file = fileStats{}
+}
+
+// reopen the active file for appending after a failed rotation
+func (r *RotateWriter) reopen() (err error) {
+	r.fp, err = appendFile(r.filename)
 	return err
 }
 
-// compressFile
-func (r *RotateWriter) compressFile(filename string) {
+// compressFile return the name of the compressed file, or filename itself if it is not compressed
+func (r *RotateWriter) compressFile(filename string) (string, error) {
+	name, err := r.compressBackup(context.Background(), filename)
+	if err != nil {
+		r.setErr(err)
+	}
+	return name, err
+}
+
+// compressBackup return the name of the backup after compression, the plain name if it failed
+func (r *RotateWriter) compressBackup(ctx context.Context, filename string) (_ string, err error) {
 	if !r.opt.gzip {
-		return
+		return filename, nil
 	}
-	if err := gzipFile(filename); err != nil {
-		r.mu.Lock()
-		defer r.mu.Unlock()
-		r.err = err
+	_, span := r.startSpan(ctx, "compress")
+	span.SetAttribute("rotate.backup", filename)
+	defer func() {
+		span.End(err)
+	}()
+	settings := r.gzipSettings()
+	compress, target := settings.file, filename+".gz"
+	if r.opt.keepOriginal {
+		compress = settings.keep
+	}
+	if r.opt.dailyGzip {
+		target = r.dailyName(filename)
+		compress = func(ctx context.Context, filename string) error {
+			return r.appendGzip(ctx, filename, target)
+		}
+	}
+	release, err := r.reserveCompress(ctx)
+	if err != nil {
+		return filename, err
+	}
+	defer release()
+	start := time.Now()
+	// cancelled on timeout rather than left running, the partial output is removed
+	compressCtx, cancel := withTaskTimeout(ctx, r.opt.timeouts.Compress)
+	defer cancel()
+	if err = compress(compressCtx, filename); err != nil {
+		if compressCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			err = taskTimeout("compress", filename)
+		}
+		return filename, err
+	}
+	r.stats.compressions.Inc()
+	r.stats.compressNanos.Add(int64(time.Since(start)))
+	r.timing("compress", start)
+	done := CompressCompleted{Original: filename, Backup: target, Duration: time.Since(start)}
+	if fi, err := os.Stat(target); err == nil {
+		done.Size = fi.Size()
+		span.SetAttribute("rotate.compressed_size", fi.Size())
 	}
+	r.emit(done)
+	return target, r.chmodBackup(target)
+}
+
+// WithBackupPerm set the permissions of backups once they are rotated, compressed or
+// recompressed, e.g. 0400 to keep finished files read-only, the active file is not affected
+func WithBackupPerm(perm os.FileMode) RotateOption {
+	return func(o *rotateOption) {
+		o.backupPerm = perm
+	}
+}
+
+// chmodBackup
+func (r *RotateWriter) chmodBackup(name string) error {
+	if r.opt.backupPerm == 0 {
+		return nil
+	}
+	return os.Chmod(name, r.opt.backupPerm)
 }
 
 // removeOutdatedFiles
 func (r *RotateWriter) removeOutdatedFiles() {
-	if r.opt.maxDays <= 0 {
+	_, maxDays := r.retention()
+	if maxDays <= 0 || r.opt.deleteAfterUpload {
 		return
 	}
 	// get old files
 	files, err := r.listFiles()
 	if err != nil {
-		r.mu.Lock()
-		defer r.mu.Unlock()
-		r.err = err
+		r.setErr(err)
 		return
 	}
-	// get outdated boundary
-	boundary := dateline(r.opt.timeFormat, r.opt.localTime, -time.Hour*time.Duration(24*r.opt.maxDays))
-	var buf strings.Builder
-	_, _ = fmt.Fprintf(&buf, "%s%s%s%s", r.prefix, r.opt.delimiter, boundary, r.ext)
-	if r.opt.gzip {
-		buf.WriteString(".gz")
-	}
-	boundaryFile := buf.String()
-
+	cutoff := r.now().Add(-time.Hour * time.Duration(24*maxDays))
+	outdated := make([]string, 0)
 	for _, file := range files {
 		// skip not outdated file
-		if file >= boundaryFile {
+		if ts, ok := r.backupTime(file); !ok || !ts.Before(cutoff) {
 			continue
 		}
-		// remove outdated file
-		if err = os.Remove(file); err != nil {
-			break
-		}
+		outdated = append(outdated, file)
 	}
 
-	if err != nil {
-		r.mu.Lock()
-		defer r.mu.Unlock()
-		r.err = err
+	if err = r.removeFiles(r.excludeRecent(outdated)); err != nil {
+		r.setErr(err)
 	}
 }
 
+// backupTime return when a backup was created, parsed from its name or its modification
+// time if the name does not carry one, e.g. a custom pattern or an older time format
+func (r *RotateWriter) backupTime(file string) (time.Time, bool) {
+	if r.customBackupPattern() {
+		return modTime(file)
+	}
+	if ts, ok := r.lumberjackTime(file); ok {
+		return ts, true
+	}
+	if ts, err := r.parseBackupTime(file); err == nil {
+		return ts, true
+	}
+	return modTime(file)
+}
+
 // removeOverMaxFiles
 func (r *RotateWriter) removeOverMaxFiles() {
-	if r.opt.maxBackups <= 0 {
+	maxBackups, _ := r.retention()
+	if maxBackups <= 0 || r.opt.deleteAfterUpload {
 		return
 	}
 	oldFiles, err := r.listFiles()
 	if err != nil {
-		r.mu.Lock()
-		defer r.mu.Unlock()
-		r.err = err
+		r.setErr(err)
 		return
 	}
 
-	sort.Strings(oldFiles)
+	if r.customBackupPattern() {
+		sortByModTime(oldFiles)
+	} else if r.opt.lumberjack {
+		r.sortLumberjackFirst(oldFiles)
+	} else {
+		r.sortBackupNames(oldFiles)
+	}
+	if r.opt.gzip && r.opt.keepOriginal {
+		r.removeOverMaxPairs(oldFiles, maxBackups)
+		return
+	}
 	remain := len(oldFiles)
-	if r.opt.maxBackups <= 0 || r.opt.maxBackups >= int64(remain) {
+	if maxBackups >= int64(remain) {
 		return
 	}
-	overMaxFiles := oldFiles[:remain-int(r.opt.maxBackups)]
-	for _, file := range overMaxFiles {
-		if err = os.Remove(file); err != nil {
-			break
-		}
+	overMaxFiles := oldFiles[:remain-int(maxBackups)]
+	if err = r.removeFiles(r.excludeRecent(overMaxFiles)); err != nil {
+		r.setErr(err)
 	}
+}
 
-	if err != nil {
-		r.mu.Lock()
-		defer r.mu.Unlock()
-		r.err = err
+// removeOverMaxPairs remove the oldest backups beyond maxBackups, a plain backup and its
+// compressed copy count as one. files must be sorted.
+func (r *RotateWriter) removeOverMaxPairs(files []string, maxBackups int64) {
+	keys := make([]string, 0, len(files))
+	forms := make(map[string][]string, len(files))
+	for _, file := range files {
+		key := strings.TrimSuffix(file, ".gz")
+		if _, ok := forms[key]; !ok {
+			keys = append(keys, key)
+		}
+		forms[key] = append(forms[key], file)
+	}
+	if maxBackups >= int64(len(keys)) {
+		return
+	}
+	overMaxFiles := make([]string, 0, len(files))
+	for _, key := range keys[:len(keys)-int(maxBackups)] {
+		overMaxFiles = append(overMaxFiles, forms[key]...)
+	}
+	if err := r.removeFiles(r.excludeRecent(overMaxFiles)); err != nil {
+		r.setErr(err)
 	}
 }
 
-// gzipFile
-func gzipFile(filename string) (err error) {
-	in, err := os.Open(filename)
-	if err != nil {
-		return err
+// truncateRecord cut data to limit bytes including a marker, the newline ending data is kept
+func truncateRecord(data []byte, limit int64) []byte {
+	if int64(len(data)) <= limit {
+		return data
 	}
-	defer func() {
-		err = multierr.Append(err, in.Close())
-	}()
-
-	out, err := os.Create(fmt.Sprintf("%s.gz", filename))
-	if err != nil {
-		return err
+	marker := fmt.Sprintf("...[truncated, %d bytes total]", len(data))
+	if data[len(data)-1] == '\n' {
+		marker += "\n"
 	}
-	defer func() {
-		err = multierr.Append(err, out.Close())
-	}()
-
-	w := gzip.NewWriter(out)
-	if _, err = io.Copy(w, in); err != nil {
-		return err
-	} else if err = w.Close(); err != nil {
-		return err
+	keep := limit - int64(len(marker))
+	if keep < 0 {
+		return []byte(marker)[:limit]
 	}
+	buf := make([]byte, 0, limit)
+	buf = append(buf, data[:keep]...)
+	return append(buf, marker...)
+}
 
-	return os.Remove(filename)
+// setErr save a background error, it is returned by the next Write
+func (r *RotateWriter) setErr(err error) {
+	r.stats.lastErr.Store(err)
+	r.stats.errors.Inc()
+	r.errMu.Lock()
+	r.err = err
+	r.errMu.Unlock()
+	r.keepErr(err)
+	r.emit(BackgroundError{Err: err})
 }
 
-// closeOnExec makes sure closing the writer on process forking.
-func closeOnExec(file *os.File) {
-	if file == nil {
-		return
-	}
-	syscall.CloseOnExec(int(file.Fd()))
+// takeErr return and clear the error reported since the last write
+func (r *RotateWriter) takeErr() error {
+	r.errMu.Lock()
+	defer r.errMu.Unlock()
+	err := r.err
+	r.err = nil
+	return err
 }
 
-// nowDate
-func nowDate(format string, local bool) string {
-	if !local {
-		return time.Now().UTC().Format(format)
-	}
-	return time.Now().Format(format)
+// gzipFile
+func gzipFile(filename string) error {
+	return defaultGzip.file(context.Background(), filename)
 }
 
-// dateline
-func dateline(format string, local bool, delay time.Duration) string {
+// nowDate
+func nowDate(now time.Time, format string, local bool) string {
 	if !local {
-		return time.Now().UTC().Add(delay).Format(format)
+		return formatTime(now.UTC(), format)
 	}
-	return time.Now().Add(delay).Format(format)
+	return formatTime(now, format)
 }
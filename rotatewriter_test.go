@@ -1,12 +1,14 @@
 package rotate
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 )
@@ -126,6 +128,136 @@ func TestRotateWriter_Write(t *testing.T) {
 	})
 }
 
+func TestRotateWriter_WithEnsureNewline(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(tmpFileName, WithEnsureNewline(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("test")
+	if n, err := writer.Write(data); err != nil {
+		t.Fatal(err)
+	} else if n != len(data) {
+		t.Errorf("written count incorrect, got:%v", n)
+	}
+	if _, err := writer.Write([]byte("done\n")); err != nil {
+		t.Fatal(err)
+	}
+	if writer.size != 10 {
+		t.Errorf("added newline should count toward size, got:%v", writer.size)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ioutil.ReadFile(tmpFileName); err != nil {
+		t.Fatal(err)
+	} else if string(got) != "test\ndone\n" || string(data) != "test" {
+		t.Errorf("content incorrect, got:%q", got)
+	}
+}
+
+func TestRotateWriter_WithMaxMessageSize(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(tmpFileName, WithMaxMessageSize(40))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.opt.maxSize = 10
+	backupName := writer.backupName
+
+	big := strings.Repeat("x", 20) + "\n"
+	for _, data := range []string{"abc\n", big} {
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer os.Remove(backupName)
+	if got, err := ioutil.ReadFile(backupName); err != nil {
+		t.Fatal(err)
+	} else if string(got) != "abc\n" {
+		t.Errorf("backup content incorrect, got:%q", got)
+	}
+	if got, err := ioutil.ReadFile(tmpFileName); err != nil {
+		t.Fatal(err)
+	} else if string(got) != big {
+		t.Errorf("large message should be written alone, got:%q", got)
+	}
+
+	if got := truncateRecord([]byte(strings.Repeat("y", 100)+"\n"), 40); len(got) != 40 ||
+		!strings.HasSuffix(string(got), "...[truncated, 101 bytes total]\n") {
+		t.Errorf("truncated record incorrect, got:%q", got)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRotateWriter_WithTruncateOnOpen(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if _, err := tmpFile.WriteString("previous run\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(tmpFileName, WithTruncateOnOpen(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	if writer.size != 5 {
+		t.Errorf("size incorrect, got:%v", writer.size)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(tmpFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "test\n" {
+		t.Errorf("content incorrect, got:%q", data)
+	}
+}
+
 func TestRotateWriter_Close(t *testing.T) {
 	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
 	if err != nil {
@@ -149,6 +281,39 @@ func TestRotateWriter_Close(t *testing.T) {
 	}
 }
 
+func TestRotateWriter_Sync(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(tmpFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte("test")); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Sync(); err != ErrLogFileClosed {
+		t.Errorf("sync after close incorrect, got:%v", err)
+	}
+}
+
 func TestRotateWriter_rotate(t *testing.T) {
 	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
 	if err != nil {
@@ -170,7 +335,7 @@ func TestRotateWriter_rotate(t *testing.T) {
 	}
 	backupName := writer.backupName
 
-	if err := writer.rotate(); err != nil {
+	if _, err := writer.rotate(); err != nil {
 		t.Fatal(err)
 	}
 	if err := os.Remove(backupName); err != nil {
@@ -178,6 +343,43 @@ func TestRotateWriter_rotate(t *testing.T) {
 	}
 }
 
+func TestRotateWriter_rotateRollback(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "rollback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+	writer, err := NewRotateWriter(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := writer.Write([]byte("before\n")); err != nil {
+		t.Fatal(err)
+	}
+	// a non empty directory in the way of the backup makes the final rename fail
+	if err := os.MkdirAll(filepath.Join(writer.backupName, "busy"), defaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.rotate(); err == nil {
+		t.Fatal("rotate should fail")
+	}
+	if _, err := writer.Write([]byte("after\n")); err != nil {
+		t.Fatalf("writer should keep its active file, got:%v", err)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "before\nafter\n" {
+		t.Errorf("active file incorrect, got:%q", data)
+	}
+	if _, err := os.Stat(filename + rotatingSuffix); !os.IsNotExist(err) {
+		t.Error("temporary file should be rolled back")
+	}
+}
+
 func TestRotateWriter_compressFile(t *testing.T) {
 	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
 	if err != nil {
@@ -242,6 +444,44 @@ func TestRotateWriter_removeOutdatedFiles(t *testing.T) {
 	}
 }
 
+func TestRotateWriter_removeOutdatedFiles_mixedFormats(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "outdated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+	writer, err := NewRotateWriter(filename, WithMaxDays(7), WithTimeFormat("2006-01-02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	old := time.Now().AddDate(0, 0, -10)
+	files := map[string]bool{
+		// name in the current format decides, whatever the modification time
+		mockBackupName(filename, old.Format("2006-01-02")):        true,
+		mockBackupName(filename, time.Now().Format("2006-01-02")): false,
+		// older format falls back to the modification time
+		mockBackupName(filename, old.Format(time.RFC3339)): true,
+	}
+	for name := range files {
+		if err := ioutil.WriteFile(name, []byte("test"), defaultFilePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(name, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writer.removeOutdatedFiles()
+	for name, outdated := range files {
+		if _, err := os.Stat(name); os.IsNotExist(err) != outdated {
+			t.Errorf("%s outdated:%v, got removed:%v", name, outdated, os.IsNotExist(err))
+		}
+	}
+}
+
 func TestRotateWriter_removeOverMaxFiles(t *testing.T) {
 	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
 	if err != nil {
@@ -302,6 +542,86 @@ func TestRotateWriter_removeOverMaxFiles(t *testing.T) {
 	}
 }
 
+func TestRotateWriter_WithKeepOriginal(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "keep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithKeepOriginal(true), WithMaxBackups(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	for i := 0; i < 2; i++ {
+		name := mockBackupName(writer.filename, fmt.Sprintf("2020-01-0%d", i+1))
+		if err := ioutil.WriteFile(name, []byte("test"), defaultFilePerm); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := writer.compressFile(name); got != name+".gz" {
+			t.Fatalf("compressed name incorrect, got:%v", got)
+		}
+		if _, err := os.Stat(name); err != nil {
+			t.Fatalf("original should be kept: %v", err)
+		}
+	}
+
+	writer.removeOverMaxFiles()
+	if writer.err != nil {
+		t.Fatal(writer.err)
+	}
+	files, err := writer.listFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	want := mockBackupName(writer.filename, "2020-01-02")
+	if !reflect.DeepEqual(files, []string{want, want + ".gz"}) {
+		t.Errorf("retention incorrect, got:%v", files)
+	}
+}
+
+func TestRotateWriter_WithBackupPerm(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "perm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+
+	for _, gzip := range []bool{false, true} {
+		writer, err := NewRotateWriter(filename, WithGzip(gzip), WithBackupPerm(0400))
+		if err != nil {
+			t.Fatal(err)
+		}
+		backupName := writer.backupName
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if gzip {
+			backupName += ".gz"
+		}
+		for name, want := range map[string]os.FileMode{backupName: 0400, filename: defaultFilePerm} {
+			fi, err := os.Stat(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fi.Mode().Perm()&0600 != want&0600 {
+				t.Errorf("%s permissions incorrect, got:%v", name, fi.Mode())
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Second) // next backup gets another name
+	}
+}
+
 func TestRotateWriter_backupFileName(t *testing.T) {
 	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
 	if err != nil {
@@ -322,7 +642,7 @@ func TestRotateWriter_backupFileName(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	wantName := mockBackupName(tmpFileName, nowDate(writer.opt.timeFormat, writer.opt.localTime))
+	wantName := mockBackupName(tmpFileName, nowDate(time.Now(), writer.opt.timeFormat, writer.opt.localTime))
 	gotName := writer.backupFileName()
 	if wantName != gotName {
 		t.Errorf("backupName incorrect, got:%v, want:%v", gotName, wantName)
@@ -343,12 +663,40 @@ func TestRotateWriter_gzipFile(t *testing.T) {
 	if err := gzipFile(tmpFileName); err != nil {
 		t.Fatal(err)
 	}
+	if _, err := os.Stat(tmpFileName + ".gz.tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file incorrect, got:%v", err)
+	}
 
 	if err := os.Remove(tmpFileName + ".gz"); err != nil {
 		t.Fatal(err)
 	}
 }
 
+func TestRotateWriter_gzipFileFailed(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "gzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// reading a directory fail once the output is created
+	source := filepath.Join(dir, "app.log")
+	if err := os.Mkdir(source, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipFile(source); err == nil {
+		t.Fatal("gzip of a directory should fail")
+	}
+	for _, name := range []string{source + ".gz", source + ".gz.tmp"} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("%s incorrect, got:%v", name, err)
+		}
+	}
+	if _, err := os.Stat(source); err != nil {
+		t.Errorf("source incorrect, got:%v", err)
+	}
+}
+
 func TestRotateWriter_oldFiles(t *testing.T) {
 	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
 	if err != nil {
@@ -395,6 +743,33 @@ func TestRotateWriter_oldFiles(t *testing.T) {
 	}
 }
 
+func TestRotateWriter_globMetacharacters(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "glob[1]?*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "app[prod].log")
+	writer, err := NewRotateWriter(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backupName := writer.backupName
+	if _, err := writer.rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := writer.listFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0] != backupName {
+		t.Errorf("backups incorrect, got:%v, want:%v", files, backupName)
+	}
+}
+
 func mockBackupName(name string, date string) string {
 	ext := filepath.Ext(name)
 	prefix := name[:len(name)-len(ext)]
@@ -402,3 +777,150 @@ func mockBackupName(name string, date string) string {
 	wantName := fmt.Sprintf("%s%s%s%s", prefix, delimiter, date, ext)
 	return wantName
 }
+
+func TestRotateWriter_WriteContext(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "writecontext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	uploader := &blockingUploader{release: make(chan struct{})}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithUploader(uploader), WithMaxPendingBackups(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	// waiting for the lock
+	writer.mu.Lock()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	_, err = writer.WriteContext(ctx, []byte("test\n"))
+	cancel()
+	writer.mu.Unlock()
+	if err != context.DeadlineExceeded {
+		t.Errorf("locked write incorrect, got:%v", err)
+	}
+	if _, err := writer.WriteContext(context.Background(), []byte("test\n")); err != nil {
+		t.Errorf("lock should be released after a canceled write, got:%v", err)
+	}
+
+	// waiting for backpressure
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err = writer.WriteContext(ctx, []byte("test\n")); err != context.DeadlineExceeded {
+		t.Errorf("pending backup write incorrect, got:%v", err)
+	}
+	close(uploader.release)
+	if _, err := writer.WriteContext(context.Background(), []byte("test\n")); err != nil {
+		t.Error(err)
+	}
+	if dropped := writer.Stats().Dropped; dropped != 2 {
+		t.Errorf("dropped incorrect, got:%v", dropped)
+	}
+}
+
+func TestRotateWriter_Rotate(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "rotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backupName := writer.backupName
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := writer.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup != backupName {
+		t.Errorf("backup incorrect, got:%s", backup)
+	}
+	if data, err := ioutil.ReadFile(backup); err != nil || string(data) != "test\n" {
+		t.Errorf("backup content incorrect, got:%q %v", data, err)
+	}
+}
+
+func TestRotateWriter_WithSyncCompress(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "synccompress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	uploader := &blockingUploader{release: make(chan struct{})}
+	defer close(uploader.release)
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithSyncCompress(true),
+		WithUploader(uploader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backupName := writer.backupName
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := writer.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup != backupName+".gz" {
+		t.Errorf("backup incorrect, got:%s", backup)
+	}
+	// the worker is blocked on the upload, the rotation compressed the backup itself
+	if _, err := os.Stat(backupName + ".gz"); err != nil {
+		t.Errorf("backup should be compressed, got:%v", err)
+	}
+	if _, err := os.Stat(backupName); !os.IsNotExist(err) {
+		t.Errorf("plain backup should be removed, got:%v", err)
+	}
+	if compressions := writer.Stats().Compressions; compressions != 1 {
+		t.Errorf("compressions incorrect, got:%d", compressions)
+	}
+}
+
+func TestRotateWriter_WithMinRotateInterval(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "minrotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clock := &manualClock{now: time.Now()}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithMinRotateInterval(time.Minute), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	writer.opt.maxSize = 10
+	write := func() {
+		if _, err := writer.Write([]byte("123456789\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		write()
+	}
+	if rotations := writer.Stats().Rotations; rotations != 1 {
+		t.Errorf("rotations within the interval incorrect, got:%d", rotations)
+	}
+	if writer.size != 40 {
+		t.Errorf("the file should grow past the size limit, got:%d", writer.size)
+	}
+
+	clock.Add(time.Minute)
+	write()
+	if rotations := writer.Stats().Rotations; rotations != 2 || writer.size != 10 {
+		t.Errorf("rotation after the interval incorrect, got:%d size:%d", rotations, writer.size)
+	}
+}
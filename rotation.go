@@ -0,0 +1,102 @@
+package rotate
+
+import (
+	"context"
+)
+
+// Rotation follow the background work of one rotation, see RotateAsync
+type Rotation struct {
+	done   chan struct{}
+	backup string
+	err    error
+}
+
+// Done is closed once the backup is compressed and uploaded, or the writer is closed
+func (f *Rotation) Done() <-chan struct{} {
+	return f.done
+}
+
+// Backup return the final name of the backup, the compressed one with WithGzip, valid once Done is closed
+func (f *Rotation) Backup() string {
+	<-f.done
+	return f.backup
+}
+
+// Err return the compression or upload error of the backup, valid once Done is closed
+func (f *Rotation) Err() error {
+	<-f.done
+	return f.err
+}
+
+// Wait block until the rotation is done or ctx and return the final name of the backup
+func (f *Rotation) Wait(ctx context.Context) (string, error) {
+	select {
+	case <-f.done:
+		return f.backup, f.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// finish
+func (f *Rotation) finish(backup string, err error) {
+	f.backup, f.err = backup, err
+	close(f.done)
+}
+
+// RotateAsync rotate like Rotate and return a Rotation done once the background work of the backup
+// is over, so callers can ship the compressed backup without polling. The Rotation is already done
+// when there was nothing to back up.
+func (r *RotateWriter) RotateAsync() (*Rotation, error) {
+	f := &Rotation{done: make(chan struct{})}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextRotation = f
+	backup, err := r.rotateLocked()
+	claimed := r.nextRotation == nil
+	r.nextRotation = nil
+	if claimed {
+		return f, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	f.finish(backup, nil)
+	return f, nil
+}
+
+// trackRotation hand the Rotation waiting for the next rotation to the backup plain, the lock must be held
+func (r *RotateWriter) trackRotation(plain string) {
+	if r.nextRotation == nil {
+		return
+	}
+	r.rotationsMu.Lock()
+	if r.rotations == nil {
+		r.rotations = make(map[string]*Rotation)
+	}
+	r.rotations[plain] = r.nextRotation
+	r.rotationsMu.Unlock()
+	r.nextRotation = nil
+}
+
+// finishRotation complete the Rotation of the backup plain if any
+func (r *RotateWriter) finishRotation(plain, backup string, err error) {
+	r.rotationsMu.Lock()
+	f, ok := r.rotations[plain]
+	delete(r.rotations, plain)
+	r.rotationsMu.Unlock()
+	if ok {
+		f.finish(backup, err)
+	}
+}
+
+// finishRotations complete every pending Rotation with err
+func (r *RotateWriter) finishRotations(err error) {
+	r.rotationsMu.Lock()
+	rotations := r.rotations
+	r.rotations = nil
+	r.rotationsMu.Unlock()
+	for plain, f := range rotations {
+		f.finish(plain, err)
+	}
+}
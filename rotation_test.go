@@ -0,0 +1,84 @@
+package rotate
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_RotateAsync(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "rotateasync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	uploader := &mockUploader{}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithUploader(uploader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backupName := writer.backupName
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	rotation, err := writer.RotateAsync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	backup, err := rotation.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup != backupName+".gz" || rotation.Backup() != backup {
+		t.Errorf("backup incorrect, got:%s", backup)
+	}
+	if !gzipComplete(backup) {
+		t.Errorf("backup should be compressed")
+	}
+	uploader.mu.Lock()
+	defer uploader.mu.Unlock()
+	if len(uploader.files) != 1 || uploader.files[0] != backup {
+		t.Errorf("uploaded incorrect, got:%v", uploader.files)
+	}
+}
+
+func TestRotateWriter_RotateAsync_closed(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "rotateasync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	uploader := &blockingUploader{release: make(chan struct{})}
+	defer close(uploader.release)
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithUploader(uploader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	rotation, err := writer.RotateAsync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rotation.Done():
+	case <-time.After(time.Second):
+		t.Fatal("rotation should be done once the writer is closed")
+	}
+	if err := rotation.Err(); !errors.Is(err, ErrLogFileClosed) {
+		t.Errorf("error incorrect, got:%v", err)
+	}
+}
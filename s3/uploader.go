@@ -0,0 +1,289 @@
+// Package s3 provide a rotate.Uploader for Amazon S3 and S3 compatible storage like MinIO and Ceph RGW.
+package s3
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/AlfredAlan/rotate"
+	"go.uber.org/multierr"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRegion = "us-east-1"
+	service       = "s3"
+	algorithm     = "AWS4-HMAC-SHA256"
+	amzDateFormat = "20060102T150405Z"
+	dateFormat    = "20060102"
+)
+
+var ErrBucketIsEmpty = errors.New("error: bucket is empty")
+
+type (
+	// Uploader put backups into a bucket with AWS signature version 4
+	Uploader struct {
+		bucket string
+		opt    *uploaderOption
+	}
+
+	uploaderOption struct {
+		endpoint     string
+		region       string
+		prefix       string
+		logDir       string
+		pathStyle    bool
+		accessKey    string
+		secretKey    string
+		sessionToken string
+		client       *http.Client
+	}
+	Option func(*uploaderOption)
+)
+
+var _ rotate.Uploader = (*Uploader)(nil)
+
+// NewUploader upload to bucket, credentials default to AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+func NewUploader(bucket string, options ...Option) (*Uploader, error) {
+	if len(bucket) == 0 {
+		return nil, ErrBucketIsEmpty
+	}
+	opt := &uploaderOption{
+		region:       defaultRegion,
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		client:       http.DefaultClient,
+	}
+	if region := os.Getenv("AWS_REGION"); len(region) > 0 {
+		opt.region = region
+	}
+	for _, fn := range options {
+		fn(opt)
+	}
+	if len(opt.endpoint) == 0 {
+		opt.endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", opt.region)
+	}
+	if _, err := url.Parse(opt.endpoint); err != nil {
+		return nil, err
+	}
+	return &Uploader{bucket: bucket, opt: opt}, nil
+}
+
+// WithEndpoint use a custom endpoint like http://minio:9000 instead of AWS
+func WithEndpoint(endpoint string) Option {
+	return func(o *uploaderOption) {
+		o.endpoint = strings.TrimRight(endpoint, "/")
+	}
+}
+
+// WithRegion
+func WithRegion(region string) Option {
+	return func(o *uploaderOption) {
+		if len(region) == 0 {
+			o.region = defaultRegion
+			return
+		}
+		o.region = region
+	}
+}
+
+// WithPrefix put objects under prefix, e.g. "logs/host-1/"
+func WithPrefix(prefix string) Option {
+	return func(o *uploaderOption) {
+		o.prefix = prefix
+	}
+}
+
+// WithLogDir key objects by their path relative to dir, the directory of the log file, so
+// backups in the sub directories of a "/" time format don't overwrite each other. Objects are
+// keyed by the base name of the backup by default
+func WithLogDir(dir string) Option {
+	return func(o *uploaderOption) {
+		o.logDir = dir
+	}
+}
+
+// WithPathStyle address objects as endpoint/bucket/key instead of bucket.endpoint/key,
+// required by most MinIO and Ceph RGW deployments
+func WithPathStyle(pathStyle bool) Option {
+	return func(o *uploaderOption) {
+		o.pathStyle = pathStyle
+	}
+}
+
+// WithCredentials use static credentials, token may be empty
+func WithCredentials(accessKey, secretKey, token string) Option {
+	return func(o *uploaderOption) {
+		o.accessKey = accessKey
+		o.secretKey = secretKey
+		o.sessionToken = token
+	}
+}
+
+// WithHTTPClient
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *uploaderOption) {
+		if client == nil {
+			o.client = http.DefaultClient
+			return
+		}
+		o.client = client
+	}
+}
+
+// key return the object key of filename without the prefix
+func (u *Uploader) key(filename string) string {
+	if len(u.opt.logDir) > 0 {
+		rel, err := filepath.Rel(u.opt.logDir, filename)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.Base(filename)
+}
+
+// Upload put filename into the bucket and return the object url
+func (u *Uploader) Upload(ctx context.Context, filename string) (_ string, err error) {
+	fp, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		err = multierr.Append(err, fp.Close())
+	}()
+
+	h := sha256.New()
+	size, err := io.Copy(h, fp)
+	if err != nil {
+		return "", err
+	}
+	if _, err = fp.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	objectURL, err := u.objectURL(u.opt.prefix + u.key(filename))
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPut, objectURL.String(), ioutil.NopCloser(fp))
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.ContentLength = size
+	u.sign(req, hex.EncodeToString(h.Sum(nil)), time.Now())
+
+	resp, err := u.opt.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		err = multierr.Append(err, resp.Body.Close())
+	}()
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("error: s3 upload %s: %s: %s", filename, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return objectURL.String(), nil
+}
+
+// objectURL
+func (u *Uploader) objectURL(key string) (*url.URL, error) {
+	endpoint, err := url.Parse(u.opt.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.opt.pathStyle {
+		endpoint.Path = path.Join("/", endpoint.Path, u.bucket, key)
+	} else {
+		endpoint.Host = u.bucket + "." + endpoint.Host
+		endpoint.Path = path.Join("/", endpoint.Path, key)
+	}
+	endpoint.RawPath = uriEncode(endpoint.Path)
+	return endpoint, nil
+}
+
+// sign add an AWS signature version 4 authorization header to req
+func (u *Uploader) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format(amzDateFormat)
+	date := now.UTC().Format(dateFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if len(u.opt.sessionToken) > 0 {
+		req.Header.Set("X-Amz-Security-Token", u.opt.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		_, _ = fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := strings.Join([]string{date, u.opt.region, service, "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{algorithm, amzDate, scope, hex.EncodeToString(requestHash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+u.opt.secretKey), date)
+	key = hmacSHA256(key, u.opt.region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, u.opt.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+// hmacSHA256
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// uriEncode escape path the way AWS signature version 4 expects
+func uriEncode(s string) string {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			buf.WriteByte(c)
+		default:
+			_, _ = fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,66 @@
+package rotate
+
+import "time"
+
+const partialRecordRetry = 100 * time.Millisecond
+
+// WithRotateInterval rotate the active file once it is older than d even if it is not full,
+// files are rotated on idle hosts too
+func WithRotateInterval(d time.Duration) RotateOption {
+	return func(o *rotateOption) {
+		o.rotateInterval = d
+	}
+}
+
+// LastRotation return the time of the most recent rotation, zero if the writer never rotated
+func (r *RotateWriter) LastRotation() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rotatedAt
+}
+
+// NextPlannedRotation return when the interval trigger will rotate the active file,
+// false if no interval is configured
+func (r *RotateWriter) NextPlannedRotation() (time.Time, bool) {
+	if r.opt.rotateInterval <= 0 {
+		return time.Time{}, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.openedAt.Add(r.opt.rotateInterval), true
+}
+
+// rotateOnInterval
+func (r *RotateWriter) rotateOnInterval() {
+	timer := time.NewTimer(r.opt.rotateInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			timer.Reset(r.rotateIfDue())
+		case <-r.postDone:
+			return
+		}
+	}
+}
+
+// rotateIfDue rotate the active file if it is older than the interval and return the time until the next check
+func (r *RotateWriter) rotateIfDue() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done.Load() {
+		return r.opt.rotateInterval
+	}
+	// a size triggered rotation may have postponed the next one
+	if wait := r.openedAt.Add(r.opt.rotateInterval).Sub(r.now()); wait > 0 {
+		return wait
+	}
+	if r.opt.jsonLines && r.partial {
+		// wait for the pending record to complete
+		return partialRecordRetry
+	}
+	if _, err := r.rotate(); err != nil {
+		r.setErr(err)
+	}
+	return r.opt.rotateInterval
+}
@@ -0,0 +1,51 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_rotateInterval(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	interval := 100 * time.Millisecond
+	writer, err := NewRotateWriter(tmpFileName, WithRotateInterval(interval))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backupName := writer.backupName
+	defer os.Remove(backupName)
+
+	if !writer.LastRotation().IsZero() {
+		t.Errorf("writer should not have rotated yet")
+	}
+	planned, ok := writer.NextPlannedRotation()
+	if !ok || time.Until(planned) > interval {
+		t.Errorf("next planned rotation incorrect, got:%v", planned)
+	}
+
+	time.Sleep(interval + 50*time.Millisecond)
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if writer.LastRotation().IsZero() {
+		t.Fatal("writer should have rotated on interval")
+	}
+	if _, err := os.Stat(backupName); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,100 @@
+package rotate
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"regexp"
+	"runtime"
+	"sync"
+)
+
+// Match is a line matched by Search, Err is set instead when File could not be scanned
+type Match struct {
+	File string
+	Line int // 1-based line number within File
+	Text []byte
+	Err  error
+}
+
+// Search scan backups and the live file in parallel and stream the lines accepted by matcher,
+// matches of one file are in order but files are interleaved. The channel is closed once every
+// file is scanned or ctx is done.
+func (r *RotateWriter) Search(ctx context.Context, matcher func(line []byte) bool) (<-chan Match, error) {
+	names, err := r.readOrder()
+	if err != nil {
+		return nil, err
+	}
+	files := make(chan string, len(names))
+	for _, name := range names {
+		files <- name
+	}
+	close(files)
+
+	workers := runtime.NumCPU()
+	if workers > len(names) {
+		workers = len(names)
+	}
+	ch := make(chan Match)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for name := range files {
+				if !r.searchFile(ctx, name, matcher, ch) {
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(ch)
+	}()
+	return ch, nil
+}
+
+// SearchRegexp stream the lines matching re, see Search
+func (r *RotateWriter) SearchRegexp(ctx context.Context, re *regexp.Regexp) (<-chan Match, error) {
+	return r.Search(ctx, re.Match)
+}
+
+// searchFile return false once ctx is done
+func (r *RotateWriter) searchFile(ctx context.Context, name string, matcher func(line []byte) bool, ch chan<- Match) bool {
+	send := func(m Match) bool {
+		select {
+		case ch <- m:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	fp, err := r.openBackup(name)
+	if os.IsNotExist(err) {
+		// removed by retention after the names were listed
+		return true
+	} else if err != nil {
+		return send(Match{File: name, Err: err})
+	}
+	defer fp.Close()
+
+	scanner := bufio.NewScanner(fp)
+	scanner.Buffer(make([]byte, 0, 64*1024), int(r.maxRecord()))
+	for line := 1; scanner.Scan(); line++ {
+		if ctx.Err() != nil {
+			return false
+		}
+		if !matcher(scanner.Bytes()) {
+			continue
+		}
+		if !send(Match{File: name, Line: line, Text: append([]byte(nil), scanner.Bytes()...)}) {
+			return false
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return send(Match{File: name, Err: err})
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"testing"
+)
+
+func TestRotateWriter_SearchRegexp(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(tmpFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backupName := writer.backupName
+	if err := ioutil.WriteFile(backupName, []byte("ok\nerror: disk\n"), defaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipFile(backupName); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(backupName + ".gz")
+	if _, err := writer.Write([]byte("error: net\nok\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	ch, err := writer.SearchRegexp(context.Background(), regexp.MustCompile(`^error`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]int)
+	for m := range ch {
+		if m.Err != nil {
+			t.Fatal(m.Err)
+		}
+		got[string(m.Text)] = m.Line
+	}
+	if len(got) != 2 || got["error: disk"] != 2 || got["error: net"] != 1 {
+		t.Errorf("matches incorrect, got:%v", got)
+	}
+}
@@ -0,0 +1,39 @@
+package rotate
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/multierr"
+)
+
+// maxKeptErrors bound the background errors kept for Close
+const maxKeptErrors = 32
+
+// Shutdown wait for the pending compressions, uploads and cleanups like Wait then close the
+// writer, the error combine the drain failure, the background errors and the close errors
+func (r *RotateWriter) Shutdown(ctx context.Context) error {
+	err := r.Wait(ctx)
+	return multierr.Append(err, r.Close())
+}
+
+// keepErr record a background error for Close
+func (r *RotateWriter) keepErr(err error) {
+	r.errMu.Lock()
+	defer r.errMu.Unlock()
+	if len(r.kept) < maxKeptErrors {
+		r.kept = append(r.kept, err)
+		return
+	}
+	r.keptDropped++
+}
+
+// keptErrs combine the background errors of the writer lifetime
+func (r *RotateWriter) keptErrs() error {
+	r.errMu.Lock()
+	defer r.errMu.Unlock()
+	err := multierr.Combine(r.kept...)
+	if r.keptDropped > 0 {
+		err = multierr.Append(err, fmt.Errorf("error: %d more background errors", r.keptDropped))
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package rotate
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/multierr"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_Shutdown(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "shutdown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	uploader := &flakyUploader{fail: true}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithUploader(uploader),
+		WithClock(&stepClock{now: time.Now()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < maxKeptErrors+2; i++ {
+		if _, err := writer.Write([]byte("test\n")); err != nil && err.Error() != "upload failed" {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = writer.Shutdown(context.Background())
+	errs := multierr.Errors(err)
+	if len(errs) != maxKeptErrors+1 || errs[0].Error() != "upload failed" ||
+		errs[maxKeptErrors].Error() != "error: 2 more background errors" {
+		t.Errorf("shutdown error incorrect, got:%v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Errorf("close twice incorrect, got:%v", err)
+	}
+
+	writer, err = NewRotateWriter(filepath.Join(dir, "app.log"), WithUploader(&blockingUploader{release: make(chan struct{})}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := writer.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("drain error incorrect, got:%v", err)
+	}
+}
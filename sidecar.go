@@ -0,0 +1,73 @@
+package rotate
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+)
+
+type (
+	// BackupMeta is the content of a backup sidecar
+	BackupMeta struct {
+		Filename   string    `json:"filename"`
+		FirstWrite time.Time `json:"first_write"`
+		LastWrite  time.Time `json:"last_write"`
+		Lines      int64     `json:"lines"`
+		Bytes      int64     `json:"bytes"`    // uncompressed size
+		Checksum   string    `json:"checksum"` // hex encoded sha256 of Filename as stored
+	}
+
+	// fileStats describe what was written to a file
+	fileStats struct {
+		first, last time.Time
+		lines       int64
+		bytes       int64
+	}
+)
+
+// WithSidecar write a hidden .name.meta.json file next to every backup, see BackupMeta,
+// the sidecar is removed together with its backup
+func WithSidecar(enable bool) RotateOption {
+	return func(o *rotateOption) {
+		o.sidecar = enable
+	}
+}
+
+// track
+func (f *fileStats) track(data []byte, now time.Time) {
+	if f.first.IsZero() {
+		f.first = now
+	}
+	f.last = now
+	f.lines += int64(bytes.Count(data, []byte{'\n'}))
+}
+
+// sidecarName is hidden so it never matches the backup patterns
+func sidecarName(backup string) string {
+	return filepath.Join(filepath.Dir(backup), "."+filepath.Base(backup)+".meta.json")
+}
+
+// writeSidecar
+func (r *RotateWriter) writeSidecar(b backup) error {
+	if !r.opt.sidecar {
+		return nil
+	}
+	_, sum, err := checksumFile(b.name)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(BackupMeta{
+		Filename:   b.name,
+		FirstWrite: b.file.first,
+		LastWrite:  b.file.last,
+		Lines:      b.file.lines,
+		Bytes:      b.file.bytes,
+		Checksum:   sum,
+	})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(sidecarName(b.name), append(data, '\n'), defaultFilePerm)
+}
@@ -0,0 +1,67 @@
+package rotate
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotateWriter_WithSidecar(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "sidecar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithSidecar(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	backupName := writer.backupName + ".gz"
+	for _, line := range []string{"first\n", "second\n"} {
+		if _, err := writer.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(sidecarName(backupName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta BackupMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatal(err)
+	}
+	_, sum, err := checksumFile(backupName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Filename != backupName || meta.Lines != 2 || meta.Bytes != 13 || meta.Checksum != sum ||
+		meta.FirstWrite.IsZero() || meta.LastWrite.Before(meta.FirstWrite) {
+		t.Errorf("sidecar incorrect, got:%+v", meta)
+	}
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Errorf("sidecar should not be listed as a backup, got:%+v", backups)
+	}
+
+	if err := writer.removeFiles([]string{backupName}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(sidecarName(backupName)); !os.IsNotExist(err) {
+		t.Error("sidecar should be removed with its backup")
+	}
+}
@@ -0,0 +1,33 @@
+package rotate
+
+import (
+	"os"
+	"os/signal"
+)
+
+// WithSyncOnSignal fsync the active file whenever one of sigs is received, e.g. before taking
+// a disk snapshot, default to SIGUSR1 if no signal is given, on platforms without SIGUSR1
+// a signal must be given
+func WithSyncOnSignal(sigs ...os.Signal) RotateOption {
+	return func(o *rotateOption) {
+		if len(sigs) == 0 {
+			sigs = defaultSyncSignals()
+		}
+		o.syncSignals = sigs
+	}
+}
+
+// syncOnSignal
+func (r *RotateWriter) syncOnSignal(ch chan os.Signal) {
+	defer signal.Stop(ch)
+	for {
+		select {
+		case <-ch:
+			if err := r.Sync(); err != nil {
+				r.setErr(err)
+			}
+		case <-r.postDone:
+			return
+		}
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package rotate
+
+import "os"
+
+// defaultSyncSignals is empty without SIGUSR1
+func defaultSyncSignals() []os.Signal {
+	return nil
+}
+
+// registrySignals return the signals of HandleSignals
+func registrySignals() (rotate, flush, stop []os.Signal) {
+	return nil, nil, []os.Signal{os.Interrupt}
+}
+
+// raise exit as an interrupt would have, the signal cannot be delivered again
+func raise(os.Signal) {
+	os.Exit(1)
+}
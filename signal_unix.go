@@ -0,0 +1,26 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package rotate
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// defaultSyncSignals
+func defaultSyncSignals() []os.Signal {
+	return []os.Signal{syscall.SIGUSR1}
+}
+
+// registrySignals return the signals of HandleSignals
+func registrySignals() (rotate, flush, stop []os.Signal) {
+	return []os.Signal{syscall.SIGHUP}, defaultSyncSignals(), []os.Signal{syscall.SIGTERM, os.Interrupt}
+}
+
+// raise deliver sig again with its default behavior
+func raise(sig os.Signal) {
+	signal.Reset(sig)
+	_ = syscall.Kill(os.Getpid(), sig.(syscall.Signal))
+}
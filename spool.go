@@ -0,0 +1,75 @@
+package rotate
+
+import (
+	"fmt"
+)
+
+// spoolState is guarded by the writer lock
+type spoolState struct {
+	records      [][]byte
+	bytes        int
+	dropped      int64 // records refused because the spool was full
+	droppedBytes int64
+}
+
+// WithSpool keep up to maxBytes of records in memory while writes fail with I/O errors instead
+// of returning the error, they are written in order before the next record once the file accepts
+// writes again. Records beyond maxBytes are dropped and replaced by a gap notice.
+// The spool is also flushed by Sync and Close.
+func WithSpool(maxBytes int) RotateOption {
+	return func(o *rotateOption) {
+		o.spoolSize = maxBytes
+	}
+}
+
+// spoolRecord keep a copy of data for replaySpool, the lock must be held
+func (r *RotateWriter) spoolRecord(data []byte) {
+	s := &r.spool
+	if s.bytes+len(data) > r.opt.spoolSize || s.dropped > 0 {
+		// keep the spool contiguous, everything after the first drop is lost
+		s.dropped++
+		s.droppedBytes += int64(len(data))
+		r.stats.dropped.Inc()
+		return
+	}
+	s.records = append(s.records, append([]byte(nil), data...))
+	s.bytes += len(data)
+}
+
+// replaySpool write the spooled records and the gap notice, the lock must be held
+func (r *RotateWriter) replaySpool() error {
+	s := &r.spool
+	for len(s.records) > 0 {
+		if err := r.chain(s.records[0]); err != nil {
+			return err
+		}
+		s.bytes -= len(s.records[0])
+		s.records[0] = nil
+		s.records = s.records[1:]
+	}
+	if s.dropped == 0 {
+		return nil
+	}
+	msg := r.notice(fmt.Sprintf("spool dropped %d records (%d bytes) during a write outage", s.dropped, s.droppedBytes),
+		map[string]int64{"dropped": s.dropped, "dropped_bytes": s.droppedBytes})
+	if err := r.writeRecord(msg); err != nil {
+		return err
+	}
+	s.dropped, s.droppedBytes = 0, 0
+	return nil
+}
+
+// spooling report whether records wait in the spool
+func (s *spoolState) spooling() bool {
+	return len(s.records) > 0 || s.dropped > 0
+}
+
+// chainSpooled write the spooled records before data
+func (r *RotateWriter) chainSpooled(data []byte) error {
+	if r.spool.spooling() {
+		if err := r.replaySpool(); err != nil {
+			return err
+		}
+	}
+	return r.chain(data)
+}
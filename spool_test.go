@@ -0,0 +1,95 @@
+package rotate
+
+import (
+	"go.uber.org/atomic"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestRotateWriter_WithSpool(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var broken atomic.Bool
+	outage := func(next WriteFunc) WriteFunc {
+		return func(data []byte) error {
+			if broken.Load() {
+				return &os.PathError{Op: "write", Path: "app.log", Err: syscall.EIO}
+			}
+			return next(data)
+		}
+	}
+	filename := filepath.Join(dir, "app.log")
+	writer, err := NewRotateWriter(filename, WithMiddleware(outage), WithSpool(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	broken.Store(true)
+	for _, record := range []string{"a\n", "b\n", "cccccccccc\n", "d\n"} {
+		if n, err := writer.Write([]byte(record)); err != nil || n != len(record) {
+			t.Fatalf("write during the outage should be spooled, got:%d %v", n, err)
+		}
+	}
+	if dropped := writer.Stats().Dropped; dropped != 2 {
+		t.Errorf("dropped incorrect, got:%d", dropped)
+	}
+	if err := writer.Sync(); err == nil {
+		t.Error("sync should fail while the spool can't be written")
+	}
+
+	broken.Store(false)
+	if _, err := writer.Write([]byte("e\n")); err != nil {
+		t.Fatal(err)
+	}
+	want := "a\nb\nspool dropped 2 records (13 bytes) during a write outage\ne\n"
+	if data, err := ioutil.ReadFile(filename); err != nil || string(data) != want {
+		t.Errorf("replay incorrect, got:%q %v", data, err)
+	}
+}
+
+func TestRotateWriter_WithSpool_jsonLines(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var broken atomic.Bool
+	outage := func(next WriteFunc) WriteFunc {
+		return func(data []byte) error {
+			if broken.Load() {
+				return &os.PathError{Op: "write", Path: "app.log", Err: syscall.EIO}
+			}
+			return next(data)
+		}
+	}
+	filename := filepath.Join(dir, "app.log")
+	writer, err := NewRotateWriter(filename, WithMiddleware(outage), WithSpool(10), WithJSONLines(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	broken.Store(true)
+	for _, record := range []string{"{}\n", "{}\n", "{}\n", "{}\n", "{}\n", "{}\n"} {
+		if _, err := writer.Write([]byte(record)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	broken.Store(false)
+	if _, err := writer.Write([]byte("{}\n")); err != nil {
+		t.Fatal(err)
+	}
+	want := "{}\n{}\n{}\n" + `{"dropped":3,"dropped_bytes":9,"msg":"spool dropped 3 records (9 bytes) during a write outage"}` + "\n{}\n"
+	if data, err := ioutil.ReadFile(filename); err != nil || string(data) != want {
+		t.Errorf("replay incorrect, got:%q %v", data, err)
+	}
+}
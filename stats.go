@@ -0,0 +1,75 @@
+package rotate
+
+import (
+	"go.uber.org/atomic"
+	"time"
+)
+
+type (
+	// Stats is a snapshot of the writer counters since it was created
+	Stats struct {
+		BytesWritten     int64
+		Writes           int64
+		Rotations        int64
+		Compressions     int64
+		CompressDuration time.Duration // total time spent compressing
+		DeletedBackups   int64
+		Dropped          int64 // writes rejected or failed
+		Suppressed       int64 // records filtered out by dedup or sampling
+		Syncs            int64 // flushes to disk of the active file
+		Errors           int64 // background errors
+		MirrorErrors     int64 // failures of the mirror, see WithMirror
+		ActiveSize       int64 // logical size of the active file, buffered bytes included
+		ActiveDiskSize   int64 // bytes of the active file already written to disk
+		QueueDepth       int64 // backups waiting for compression, upload or cleanup
+		CompressMemory   int64 // estimated bytes held by the compressions in flight
+		LastError        error
+	}
+
+	counters struct {
+		bytes          atomic.Int64
+		writes         atomic.Int64
+		rotations      atomic.Int64
+		compressions   atomic.Int64
+		compressNanos  atomic.Int64
+		deleted        atomic.Int64
+		dropped        atomic.Int64
+		suppressed     atomic.Int64
+		syncs          atomic.Int64
+		errors         atomic.Int64
+		mirrorErrors   atomic.Int64
+		activeSize     atomic.Int64
+		buffered       atomic.Int64
+		compressMemory atomic.Int64
+		lastErr        atomic.Error
+	}
+)
+
+// Stats return the current counters
+func (r *RotateWriter) Stats() Stats {
+	activeSize := r.stats.activeSize.Load()
+	return Stats{
+		BytesWritten:     r.stats.bytes.Load(),
+		Writes:           r.stats.writes.Load(),
+		Rotations:        r.stats.rotations.Load(),
+		Compressions:     r.stats.compressions.Load(),
+		CompressDuration: time.Duration(r.stats.compressNanos.Load()),
+		DeletedBackups:   r.stats.deleted.Load(),
+		Dropped:          r.stats.dropped.Load(),
+		Suppressed:       r.stats.suppressed.Load(),
+		Syncs:            r.stats.syncs.Load(),
+		Errors:           r.stats.errors.Load(),
+		MirrorErrors:     r.stats.mirrorErrors.Load(),
+		ActiveSize:       activeSize,
+		ActiveDiskSize:   activeSize - r.stats.buffered.Load(),
+		QueueDepth:       r.queueDepth(),
+		CompressMemory:   r.stats.compressMemory.Load(),
+		LastError:        r.stats.lastErr.Load(),
+	}
+}
+
+// noteSize publish the size of the active file to Stats, the lock must be held
+func (r *RotateWriter) noteSize() {
+	r.stats.activeSize.Store(r.size)
+	r.stats.buffered.Store(int64(len(r.buffer.data)))
+}
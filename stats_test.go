@@ -0,0 +1,50 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_Stats(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(tmpFileName, WithGzip(true), WithMaxSize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backupName := writer.backupName
+
+	if _, err := writer.Write([]byte("test")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	defer os.Remove(backupName + ".gz")
+	if _, err := writer.Write(make([]byte, megabyte+1)); err != ErrDataOversize {
+		t.Fatalf("oversize write should fail, got:%v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := writer.Stats()
+	if stats.BytesWritten != 4 || stats.Writes != 1 || stats.Rotations != 1 || stats.Compressions != 1 || stats.Dropped != 1 {
+		t.Errorf("stats incorrect, got:%+v", stats)
+	}
+}
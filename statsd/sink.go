@@ -0,0 +1,104 @@
+// Package statsd provide a rotate.MetricsSink sending the writer metrics to a StatsD server over UDP,
+// with DogStatsD tags if any.
+package statsd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/AlfredAlan/rotate"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrAddressIsEmpty = errors.New("error: statsd address is empty")
+
+// Sink send one datagram per metric, send errors are ignored like any StatsD client
+type Sink struct {
+	prefix string
+	tags   string
+	conn   net.Conn
+}
+
+// Option
+type Option func(*Sink)
+
+// WithTags append DogStatsD tags to every metric, e.g. "service:api" or "env:prod"
+func WithTags(tags ...string) Option {
+	return func(s *Sink) {
+		if len(tags) > 0 {
+			s.tags = "|#" + strings.Join(tags, ",")
+		}
+	}
+}
+
+var _ rotate.LabeledSink = (*Sink)(nil)
+
+// NewSink send to addr, e.g. 127.0.0.1:8125, names are prefixed with prefix and a dot if not empty
+func NewSink(addr, prefix string, options ...Option) (*Sink, error) {
+	if len(addr) == 0 {
+		return nil, ErrAddressIsEmpty
+	}
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if len(prefix) > 0 {
+		prefix += "."
+	}
+	s := &Sink{prefix: prefix, conn: conn}
+	for _, option := range options {
+		option(s)
+	}
+	return s, nil
+}
+
+// Counter
+func (s *Sink) Counter(name string, delta int64) {
+	s.send(name, strconv.FormatInt(delta, 10), "c")
+}
+
+// Gauge
+func (s *Sink) Gauge(name string, value float64) {
+	s.send(name, strconv.FormatFloat(value, 'f', -1, 64), "g")
+}
+
+// Timing
+func (s *Sink) Timing(name string, d time.Duration) {
+	s.send(name, strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64), "ms")
+}
+
+// WithLabels return a Sink sharing the connection whose tags also carry the labels as key:value
+func (s *Sink) WithLabels(labels map[string]string) rotate.MetricsSink {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	tags := make([]string, 0, len(keys))
+	for _, k := range keys {
+		tags = append(tags, k+":"+labels[k])
+	}
+	labeled := *s
+	if len(tags) == 0 {
+		return &labeled
+	}
+	if len(labeled.tags) == 0 {
+		labeled.tags = "|#" + strings.Join(tags, ",")
+	} else {
+		labeled.tags += "," + strings.Join(tags, ",")
+	}
+	return &labeled
+}
+
+// Close
+func (s *Sink) Close() error {
+	return s.conn.Close()
+}
+
+// send
+func (s *Sink) send(name, value, kind string) {
+	_, _ = fmt.Fprintf(s.conn, "%s%s:%s|%s%s", s.prefix, name, value, kind, s.tags)
+}
@@ -0,0 +1,92 @@
+package statsd
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := NewSink("", "app"); err != ErrAddressIsEmpty {
+		t.Errorf("empty address incorrect, got:%v", err)
+	}
+	sink, err := NewSink(conn.LocalAddr().String(), "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	sink.Counter("writes", 2)
+	sink.Gauge("queue_depth", 1.5)
+	sink.Timing("compress", 1500*time.Microsecond)
+	buf := make([]byte, 512)
+	for _, want := range []string{"app.writes:2|c", "app.queue_depth:1.5|g", "app.compress:1.5|ms"} {
+		if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+			t.Fatal(err)
+		}
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(buf[:n]); got != want {
+			t.Errorf("datagram incorrect, got:%q want:%q", got, want)
+		}
+	}
+}
+
+func TestSink_Tags(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	sink, err := NewSink(conn.LocalAddr().String(), "", WithTags("service:api", "env:prod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	sink.Counter("errors", 1)
+	buf := make([]byte, 512)
+	if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(buf[:n]), "errors:1|c|#service:api,env:prod"; got != want {
+		t.Errorf("datagram incorrect, got:%q want:%q", got, want)
+	}
+}
+
+func TestSink_WithLabels(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	sink, err := NewSink(conn.LocalAddr().String(), "", WithTags("env:prod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	sink.WithLabels(map[string]string{"writer": "access", "service": "api"}).Counter("errors", 1)
+	buf := make([]byte, 512)
+	if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(buf[:n]), "errors:1|c|#env:prod,service:api,writer:access"; got != want {
+		t.Errorf("datagram incorrect, got:%q want:%q", got, want)
+	}
+}
@@ -0,0 +1,89 @@
+package rotate
+
+import (
+	"bytes"
+	"sync"
+)
+
+// tailRing keep the last lines written in memory, see WithMemoryTail
+type tailRing struct {
+	mu      sync.Mutex
+	lines   [][]byte
+	next    int  // index of the next line to overwrite
+	full    bool // every slot holds a line
+	partial []byte
+}
+
+// WithMemoryTail keep the last n lines written in memory, returned by Tail even when they are
+// still buffered, e.g. for crash handlers and debug endpoints
+func WithMemoryTail(n int) RotateOption {
+	return func(o *rotateOption) {
+		o.memoryTail = n
+	}
+}
+
+// Tail return up to the last n lines written, oldest first and without their newline, all the
+// kept lines if n <= 0. A record not ended by a newline yet is returned as the last line.
+// It is nil without WithMemoryTail.
+func (r *RotateWriter) Tail(n int) [][]byte {
+	if r.tail == nil {
+		return nil
+	}
+	return r.tail.last(n)
+}
+
+// newTailRing
+func newTailRing(n int) *tailRing {
+	if n <= 0 {
+		return nil
+	}
+	return &tailRing{lines: make([][]byte, n)}
+}
+
+// add split data into lines, the end of data without newline is completed by the next add
+func (t *tailRing) add(data []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			t.partial = append(t.partial, data...)
+			return
+		}
+		// partial is owned by the ring, a nil partial make append copy
+		t.push(append(t.partial, data[:i]...))
+		t.partial = nil
+		data = data[i+1:]
+	}
+}
+
+// push
+func (t *tailRing) push(line []byte) {
+	t.lines[t.next] = line
+	t.next++
+	if t.next == len(t.lines) {
+		t.next, t.full = 0, true
+	}
+}
+
+// last
+func (t *tailRing) last(n int) [][]byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := make([][]byte, 0, len(t.lines)+1)
+	if t.full {
+		lines = append(lines, t.lines[t.next:]...)
+	}
+	lines = append(lines, t.lines[:t.next]...)
+	if len(t.partial) > 0 {
+		lines = append(lines, t.partial)
+	}
+	if n > 0 && n < len(lines) {
+		lines = lines[len(lines)-n:]
+	}
+	out := make([][]byte, len(lines))
+	for i, line := range lines {
+		out[i] = append([]byte(nil), line...)
+	}
+	return out
+}
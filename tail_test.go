@@ -0,0 +1,56 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotateWriter_WithMemoryTail(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "tail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithMemoryTail(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if tail := writer.Tail(0); len(tail) != 0 {
+		t.Errorf("empty tail incorrect, got:%q", tail)
+	}
+	data := []byte("line 1\nline 2\n")
+	for _, record := range [][]byte{data, []byte("line 3\nline 4\nline"), []byte(" 5")} {
+		if _, err := writer.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the caller may reuse its buffer
+	copy(data, "xxxx")
+
+	want := []string{"line 2", "line 3", "line 4", "line 5"}
+	tail := writer.Tail(0)
+	if len(tail) != len(want) {
+		t.Fatalf("tail incorrect, got:%q", tail)
+	}
+	for i, line := range tail {
+		if string(line) != want[i] {
+			t.Errorf("line %d incorrect, got:%q", i, line)
+		}
+	}
+	if tail := writer.Tail(2); len(tail) != 2 || string(tail[0]) != "line 4" {
+		t.Errorf("last lines incorrect, got:%q", tail)
+	}
+
+	plain, err := NewRotateWriter(filepath.Join(dir, "plain.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+	if tail := plain.Tail(1); tail != nil {
+		t.Errorf("tail without WithMemoryTail incorrect, got:%q", tail)
+	}
+}
@@ -0,0 +1,11 @@
+package rotate
+
+// WithTimestampPrefix prefix every record with the time formatted by layout and a space,
+// in local time unless WithLocalTime(false), see Timestamp
+func WithTimestampPrefix(layout string) RotateOption {
+	return func(o *rotateOption) {
+		o.middlewares = append(o.middlewares, func(o *rotateOption) Middleware {
+			return Timestamp(layout, !o.localTime)
+		})
+	}
+}
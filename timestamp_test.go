@@ -0,0 +1,66 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_stamp(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	layout := "2006-01-02"
+	writer, err := NewRotateWriter(tmpFileName, WithTimestampPrefix(layout), WithLocalTime(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	} else if n != 5 {
+		t.Errorf("written count should not include the prefix, got:%v", n)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(tmpFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Now().UTC().Format(layout) + " test\n"; string(got) != want {
+		t.Errorf("stamped content incorrect, got:%q, want:%q", got, want)
+	}
+
+	// a wall clock step back keeps the previous timestamp
+	future := time.Now().Add(48 * time.Hour).UTC()
+	clock := []time.Time{future, time.Now().UTC()}
+	var stamped []string
+	write := timestamp(layout, true, func() time.Time {
+		now := clock[0]
+		clock = clock[1:]
+		return now
+	})(func(data []byte) error {
+		stamped = append(stamped, string(data))
+		return nil
+	})
+	for i := 0; i < 2; i++ {
+		if err := write(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := future.Format(layout) + " "; stamped[1] != want {
+		t.Errorf("stamp went backwards, got:%q, want:%q", stamped[1], want)
+	}
+}
@@ -0,0 +1,52 @@
+package rotate
+
+import (
+	"context"
+)
+
+type (
+	// Tracer start a span named name as a child of the span in ctx. To tie rotation into
+	// OpenTelemetry traces, wrap provider.Tracer("rotate") and map SetAttribute to
+	// span.SetAttributes and End to span.RecordError and span.End.
+	Tracer interface {
+		Start(ctx context.Context, name string) (context.Context, Span)
+	}
+
+	// Span is ended once the operation is done, err is nil on success
+	Span interface {
+		SetAttribute(key string, value interface{})
+		End(err error)
+	}
+
+	noopSpan struct{}
+)
+
+// WithTracer trace rotate, compress, cleanup and upload. A rotation triggered by WriteContext
+// is a child of the span in the write context, background operations start their own trace.
+func WithTracer(tracer Tracer) RotateOption {
+	return func(o *rotateOption) {
+		o.tracer = tracer
+	}
+}
+
+// startSpan start a span if a tracer is set
+func (r *RotateWriter) startSpan(ctx context.Context, name string) (context.Context, Span) {
+	if r.opt.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return r.opt.tracer.Start(ctx, "rotate."+name)
+}
+
+// writeCtx return the context of the write in progress, the lock must be held
+func (r *RotateWriter) writeCtx() context.Context {
+	if r.traceCtx == nil {
+		return context.Background()
+	}
+	return r.traceCtx
+}
+
+// SetAttribute
+func (noopSpan) SetAttribute(string, interface{}) {}
+
+// End
+func (noopSpan) End(error) {}
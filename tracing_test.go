@@ -0,0 +1,94 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type traceKey struct{}
+
+type mockSpan struct {
+	name   string
+	parent string
+	attrs  map[string]interface{}
+	ended  bool
+}
+
+func (s *mockSpan) SetAttribute(key string, value interface{}) {
+	s.attrs[key] = value
+}
+
+func (s *mockSpan) End(error) {
+	s.ended = true
+}
+
+type mockTracer struct {
+	mu    sync.Mutex
+	spans []*mockSpan
+}
+
+func (m *mockTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	parent, _ := ctx.Value(traceKey{}).(string)
+	span := &mockSpan{name: name, parent: parent, attrs: map[string]interface{}{}}
+	m.spans = append(m.spans, span)
+	return context.WithValue(ctx, traceKey{}, name), span
+}
+
+func (m *mockTracer) find(name string) *mockSpan {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, span := range m.spans {
+		if span.name == name {
+			return span
+		}
+	}
+	return nil
+}
+
+func TestRotateWriter_WithTracer(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "tracing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tracer := &mockTracer{}
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithTracer(tracer), WithGzip(true),
+		WithSyncCompress(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	// rotate on the second write
+	writer.opt.maxSize = 8
+	ctx := context.WithValue(context.Background(), traceKey{}, "request")
+	for i := 0; i < 2; i++ {
+		if _, err := writer.WriteContext(ctx, []byte("test\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctxWait, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Wait(ctxWait); err != nil {
+		t.Fatal(err)
+	}
+
+	rotate := tracer.find("rotate.rotate")
+	if rotate == nil || rotate.parent != "request" || !rotate.ended || rotate.attrs["rotate.size"] != int64(5) {
+		t.Fatalf("rotate span incorrect, got:%+v", rotate)
+	}
+	compress := tracer.find("rotate.compress")
+	if compress == nil || compress.parent != "rotate.rotate" || compress.attrs["rotate.backup"] != rotate.attrs["rotate.backup"] {
+		t.Errorf("compress span incorrect, got:%+v", compress)
+	}
+	if cleanup := tracer.find("rotate.cleanup"); cleanup == nil || !cleanup.ended {
+		t.Errorf("cleanup span incorrect, got:%+v", cleanup)
+	}
+}
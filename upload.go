@@ -0,0 +1,292 @@
+package rotate
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"go.uber.org/multierr"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const defaultManifestSuffix = ".manifest.jsonl"
+
+var ErrNoUploader = errors.New("error: delete after upload requires an uploader")
+
+type (
+	// Uploader ship a rotated backup to remote storage and return its remote URL
+	Uploader interface {
+		Upload(ctx context.Context, filename string) (string, error)
+	}
+
+	// ManifestEntry describe one shipped backup
+	ManifestEntry struct {
+		Filename string    `json:"filename"`
+		Size     int64     `json:"size"`
+		Checksum string    `json:"checksum"` // hex encoded sha256
+		Start    time.Time `json:"start"`
+		End      time.Time `json:"end"`
+		URL      string    `json:"url"`
+	}
+)
+
+// WithUploader ship every backup after it is compressed
+func WithUploader(u Uploader) RotateOption {
+	return func(o *rotateOption) {
+		o.uploader = u
+	}
+}
+
+// WithManifest append an entry to the manifest file after each successful upload,
+// default manifest is prefix.manifest.jsonl next to the logs, upload decide whether the manifest is uploaded too
+func WithManifest(filename string, upload bool) RotateOption {
+	return func(o *rotateOption) {
+		o.manifest = true
+		o.manifestName = filename
+		o.upManifest = upload
+	}
+}
+
+// WithDeleteAfterUpload remove local backups only after they are uploaded successfully,
+// age and count based retention is disabled, failed uploads are kept and retried after the next rotation,
+// the backups a previous run left unshipped too.
+// Without WithUploader NewRotateWriter fails with ErrNoUploader, nothing would ever be removed
+func WithDeleteAfterUpload(enable bool) RotateOption {
+	return func(o *rotateOption) {
+		o.deleteAfterUpload = enable
+	}
+}
+
+// WithUploadErrorHandler call fn whenever a backup fails to upload
+func WithUploadErrorHandler(fn func(filename string, err error)) RotateOption {
+	return func(o *rotateOption) {
+		o.onUploadError = fn
+	}
+}
+
+// manifestFileName
+func (r *RotateWriter) manifestFileName() string {
+	if len(r.opt.manifestName) == 0 {
+		_, prefix, _ := r.paths()
+		return prefix + defaultManifestSuffix
+	}
+	return r.opt.manifestName
+}
+
+// uploadFile
+func (r *RotateWriter) uploadFile(ctx context.Context, b backup) (current error) {
+	if r.opt.uploader == nil {
+		return nil
+	}
+	r.failedMu.Lock()
+	pending := append(r.failed, b)
+	r.failed = nil
+	r.failedMu.Unlock()
+	var errs error
+	for _, p := range pending {
+		err := r.uploadTimeout(ctx, p)
+		if err == nil && r.opt.deleteAfterUpload {
+			if err = r.removeBackup(p.name); err == nil {
+				r.stats.deleted.Inc()
+			}
+		}
+		if err == nil {
+			continue
+		}
+		if p.name == b.name {
+			current = err
+		}
+		if r.opt.deleteAfterUpload {
+			// keep the local backup until it is shipped
+			r.failedMu.Lock()
+			r.failed = append(r.failed, p)
+			r.failedMu.Unlock()
+		}
+		if r.opt.onUploadError != nil {
+			r.opt.onUploadError(p.name, err)
+		}
+		errs = multierr.Append(errs, err)
+	}
+
+	if errs != nil {
+		r.setErr(errs)
+	}
+	return current
+}
+
+// loadFailed pick up the backups a previous run left without uploading them, called before
+// afterRotate starts so they are retried after the next rotation like any failed upload. A backup
+// the manifest lists was shipped before a crash kept it from being removed, it is removed now
+func (r *RotateWriter) loadFailed() {
+	if !r.opt.deleteAfterUpload {
+		return
+	}
+	shipped, err := r.readManifest()
+	if err != nil {
+		r.setErr(err)
+		return
+	}
+	backups, err := r.Backups()
+	if err != nil {
+		r.setErr(err)
+		return
+	}
+	skip := make(map[string]bool, len(backups)+len(r.recovered))
+	// compressed by processRecovered and uploaded then
+	for _, file := range r.recovered {
+		skip[file] = true
+	}
+	for _, b := range backups {
+		if strings.HasSuffix(b.Name, ".gz") {
+			// the original kept by WithKeepOriginal is removed with the upload of the .gz
+			skip[strings.TrimSuffix(b.Name, ".gz")] = true
+		}
+	}
+	if r.opt.dailyGzip {
+		// still collecting the backups of today
+		skip[r.dailyName(r.filename)] = true
+	}
+	for _, b := range backups {
+		if skip[b.Name] || r.isInflight(r.checkpointID(b.Name)) {
+			continue
+		}
+		if shipped[filepath.Clean(b.Name)] {
+			if err := r.removeBackup(b.Name); err != nil && !os.IsNotExist(err) {
+				r.setErr(err)
+			} else {
+				r.stats.deleted.Inc()
+			}
+			continue
+		}
+		r.failed = append(r.failed, backup{name: b.Name, start: b.Timestamp, end: b.ModTime})
+	}
+}
+
+// readManifest return the names of the backups listed in the manifest
+func (r *RotateWriter) readManifest() (_ map[string]bool, err error) {
+	shipped := make(map[string]bool)
+	if !r.opt.manifest {
+		return shipped, nil
+	}
+	fp, err := os.Open(r.manifestFileName())
+	if os.IsNotExist(err) {
+		return shipped, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = multierr.Append(err, fp.Close())
+	}()
+
+	scanner := bufio.NewScanner(fp)
+	for scanner.Scan() {
+		var e ManifestEntry
+		if err = json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, err
+		}
+		shipped[filepath.Clean(e.Filename)] = true
+	}
+	return shipped, scanner.Err()
+}
+
+// isFailed report whether name waits for an upload retry
+func (r *RotateWriter) isFailed(name string) bool {
+	r.failedMu.Lock()
+	defer r.failedMu.Unlock()
+	for _, b := range r.failed {
+		if b.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadTimeout upload b within the upload timeout
+func (r *RotateWriter) uploadTimeout(ctx context.Context, b backup) (err error) {
+	ctx, span := r.startSpan(ctx, "upload")
+	span.SetAttribute("rotate.backup", b.name)
+	span.SetAttribute("rotate.size", b.file.bytes)
+	defer func() {
+		span.End(err)
+	}()
+	if r.opt.timeouts.Upload <= 0 {
+		return r.upload(ctx, b)
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.opt.timeouts.Upload)
+	defer cancel()
+	return r.runTask(r.opt.timeouts.Upload, "upload", b.name, func() error {
+		return r.upload(ctx, b)
+	})
+}
+
+// upload
+func (r *RotateWriter) upload(ctx context.Context, b backup) error {
+	url, err := r.opt.uploader.Upload(ctx, b.name)
+	if err != nil {
+		return err
+	}
+	if !r.opt.manifest {
+		return nil
+	}
+	size, sum, err := checksumFile(b.name)
+	if err != nil {
+		return err
+	}
+	entry := ManifestEntry{
+		Filename: b.name,
+		Size:     size,
+		Checksum: sum,
+		Start:    b.start,
+		End:      b.end,
+		URL:      url,
+	}
+	manifest := r.manifestFileName()
+	if err = appendJSONLine(manifest, entry); err != nil {
+		return err
+	}
+	if r.opt.upManifest {
+		_, err = r.opt.uploader.Upload(ctx, manifest)
+	}
+	return err
+}
+
+// checksumFile return the size and hex encoded sha256 of filename
+func checksumFile(filename string) (size int64, sum string, err error) {
+	fp, err := os.Open(filename)
+	if err != nil {
+		return 0, "", err
+	}
+	defer func() {
+		err = multierr.Append(err, fp.Close())
+	}()
+
+	h := sha256.New()
+	if size, err = io.Copy(h, fp); err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// appendJSONLine
+func appendJSONLine(filename string, v interface{}) (err error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	fp, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, defaultFilePerm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Append(err, fp.Close())
+	}()
+
+	_, err = fp.Write(append(data, '\n'))
+	return err
+}
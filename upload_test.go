@@ -0,0 +1,255 @@
+package rotate
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockUploader struct {
+	mu    sync.Mutex
+	files []string
+}
+
+func (m *mockUploader) Upload(_ context.Context, filename string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files = append(m.files, filename)
+	return "mock://" + filename, nil
+}
+
+func TestRotateWriter_uploadManifest(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	uploader := &mockUploader{}
+	writer, err := NewRotateWriter(tmpFileName, WithUploader(uploader), WithManifest("", true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backupName := writer.backupName
+	manifest := writer.manifestFileName()
+	defer func(t *testing.T) {
+		if err := os.Remove(manifest); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+
+	if _, err := writer.Write([]byte("test")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(backupName); err != nil {
+		t.Fatal(err)
+	}
+
+	uploader.mu.Lock()
+	defer uploader.mu.Unlock()
+	if len(uploader.files) != 2 || uploader.files[0] != backupName || uploader.files[1] != manifest {
+		t.Fatalf("uploaded files incorrect, got:%v", uploader.files)
+	}
+
+	fp, err := os.Open(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+	scanner := bufio.NewScanner(fp)
+	if !scanner.Scan() {
+		t.Fatal("manifest is empty")
+	}
+	var entry ManifestEntry
+	if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Filename != backupName || entry.Size != 4 || entry.URL != "mock://"+backupName || len(entry.Checksum) != 64 {
+		t.Errorf("manifest entry incorrect, got:%+v", entry)
+	}
+}
+
+type flakyUploader struct {
+	mockUploader
+	fail bool
+}
+
+func (f *flakyUploader) Upload(ctx context.Context, filename string) (string, error) {
+	f.mu.Lock()
+	fail := f.fail
+	f.mu.Unlock()
+	if fail {
+		return "", errors.New("upload failed")
+	}
+	return f.mockUploader.Upload(ctx, filename)
+}
+
+func TestRotateWriter_deleteAfterUpload(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		alertMu sync.Mutex
+		alerts  []string
+	)
+	uploader := &flakyUploader{fail: true}
+	writer, err := NewRotateWriter(
+		tmpFileName,
+		WithUploader(uploader),
+		WithDeleteAfterUpload(true),
+		WithUploadErrorHandler(func(filename string, err error) {
+			alertMu.Lock()
+			defer alertMu.Unlock()
+			alerts = append(alerts, filename)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstBackup := writer.backupName
+
+	time.Sleep(time.Second) // let the second backup get a different name
+	if _, err := writer.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if _, err := os.Stat(firstBackup); err != nil {
+		t.Fatalf("failed upload should keep %s: %v", firstBackup, err)
+	}
+	alertMu.Lock()
+	if len(alerts) != 1 || alerts[0] != firstBackup {
+		t.Fatalf("upload alert incorrect, got:%v", alerts)
+	}
+	alertMu.Unlock()
+
+	uploader.mu.Lock()
+	uploader.fail = false
+	uploader.mu.Unlock()
+	secondBackup := writer.backupName
+	if _, err := writer.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	// the first failed upload is still reported
+	if err := writer.Close(); err == nil || err.Error() != "upload failed" {
+		t.Fatalf("close error incorrect, got:%v", err)
+	}
+	for _, name := range []string{firstBackup, secondBackup} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("uploaded backup %s should be removed", name)
+		}
+	}
+}
+
+func TestRotateWriter_deleteAfterUpload_noUploader(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithDeleteAfterUpload(true))
+	if !errors.Is(err, ErrNoUploader) || writer != nil {
+		t.Errorf("missing uploader should be rejected, got:%v", err)
+	}
+}
+
+func TestRotateWriter_deleteAfterUpload_restart(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+	clock := &stepClock{now: time.Now()}
+
+	writer, err := NewRotateWriter(filename, WithUploader(&flakyUploader{fail: true}), WithDeleteAfterUpload(true),
+		WithManifest("", false), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := writer.backupName
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err == nil {
+		t.Fatal("failed upload should be reported by Close")
+	}
+	// shipped by a run which crashed before removing it
+	shipped := mockBackupName(filename, time.Now().AddDate(0, 0, -1).Format(defaultTimeFormat))
+	if err := ioutil.WriteFile(shipped, []byte("test"), defaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendJSONLine(writer.manifestFileName(), ManifestEntry{Filename: shipped}); err != nil {
+		t.Fatal(err)
+	}
+
+	uploader := &mockUploader{}
+	writer, err = NewRotateWriter(filename, WithUploader(uploader), WithDeleteAfterUpload(true),
+		WithManifest("", false), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	current := writer.backupName
+	if _, err := writer.Write([]byte("test\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	uploader.mu.Lock()
+	defer uploader.mu.Unlock()
+	if len(uploader.files) != 2 || uploader.files[0] != failed || uploader.files[1] != current {
+		t.Errorf("uploads incorrect, got:%v", uploader.files)
+	}
+	for _, name := range []string{failed, shipped, current} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed, got:%v", name, err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package rotate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// ProblemKind classify the problems found by Verify
+type ProblemKind int
+
+const (
+	// ProblemCorrupt is a backup that can not be read or decompressed to the end
+	ProblemCorrupt ProblemKind = iota
+	// ProblemChecksum is a backup that does not match its sidecar, or whose sidecar is missing or invalid
+	ProblemChecksum
+	// ProblemName is a backup whose name does not follow the backup layout or that exist both
+	// compressed and uncompressed
+	ProblemName
+	// ProblemGap is a missing backup between two backups
+	ProblemGap
+)
+
+// Problem describe one problem of a backup
+type Problem struct {
+	Backup string
+	Kind   ProblemKind
+	Detail string
+}
+
+// String
+func (k ProblemKind) String() string {
+	switch k {
+	case ProblemCorrupt:
+		return "corrupt"
+	case ProblemChecksum:
+		return "checksum"
+	case ProblemName:
+		return "name"
+	case ProblemGap:
+		return "gap"
+	}
+	return "unknown"
+}
+
+// Verify check every backup: it is fully readable, matches its sidecar checksum, its name
+// follows the backup layout and no backup is missing in between. Gaps are only detected with
+// WithRotateInterval, two backups further apart than one and a half interval, or WithDateIndex,
+// a skipped index within a date. The error is only set if the backups can not be listed or ctx is done.
+func (r *RotateWriter) Verify(ctx context.Context) ([]Problem, error) {
+	backups, err := r.Backups()
+	if err != nil {
+		return nil, err
+	}
+	problems := make([]Problem, 0)
+	names := make(map[string]bool, len(backups))
+	for _, b := range backups {
+		names[b.Name] = true
+	}
+	for i, b := range backups {
+		if err := ctx.Err(); err != nil {
+			return problems, err
+		}
+		if err := r.verifyContent(b.Name); err != nil {
+			problems = append(problems, Problem{Backup: b.Name, Kind: ProblemCorrupt, Detail: err.Error()})
+		}
+		if detail := r.verifySidecar(b.Name); len(detail) > 0 {
+			problems = append(problems, Problem{Backup: b.Name, Kind: ProblemChecksum, Detail: detail})
+		}
+		if b.Timestamp.IsZero() && !r.customBackupPattern() {
+			problems = append(problems, Problem{Backup: b.Name, Kind: ProblemName, Detail: "no timestamp in name"})
+		}
+		if b.Compressed && !r.opt.keepOriginal && names[strings.TrimSuffix(b.Name, ".gz")] {
+			problems = append(problems, Problem{Backup: b.Name, Kind: ProblemName, Detail: "also exist uncompressed"})
+		}
+		if i > 0 {
+			if detail := r.verifyGap(backups[i-1], b); len(detail) > 0 {
+				problems = append(problems, Problem{Backup: b.Name, Kind: ProblemGap, Detail: detail})
+			}
+		}
+	}
+	return problems, nil
+}
+
+// verifyContent read name to the end, gzip and codecs check their own checksums
+func (r *RotateWriter) verifyContent(name string) error {
+	rc, err := r.openBackup(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(ioutil.Discard, rc)
+	if closeErr := rc.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// verifySidecar return why name does not match its sidecar, empty if it does or sidecars are off
+// and name has none
+func (r *RotateWriter) verifySidecar(name string) string {
+	data, err := ioutil.ReadFile(sidecarName(name))
+	if os.IsNotExist(err) {
+		if r.opt.sidecar {
+			return "missing sidecar"
+		}
+		return ""
+	} else if err != nil {
+		return err.Error()
+	}
+	var meta BackupMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return fmt.Sprintf("invalid sidecar: %v", err)
+	}
+	_, sum, err := checksumFile(name)
+	if err != nil {
+		return err.Error()
+	}
+	if sum != meta.Checksum {
+		return fmt.Sprintf("checksum %s, sidecar %s", sum, meta.Checksum)
+	}
+	return ""
+}
+
+// verifyGap return why a backup seems missing between prev and next, empty if none
+func (r *RotateWriter) verifyGap(prev, next BackupInfo) string {
+	if prev.Timestamp.IsZero() || next.Timestamp.IsZero() {
+		return ""
+	}
+	if r.opt.dateIndex && prev.Timestamp.Equal(next.Timestamp) {
+		if p, n := r.backupIndex(prev.Name), r.backupIndex(next.Name); n > p+1 {
+			return fmt.Sprintf("index %d to %d", p, n)
+		}
+		return ""
+	}
+	if r.opt.rotateInterval <= 0 {
+		return ""
+	}
+	if d := next.Timestamp.Sub(prev.Timestamp); d > r.opt.rotateInterval*3/2 {
+		return fmt.Sprintf("%s since %s", d, prev.Name)
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_Verify(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewRotateWriter(filepath.Join(dir, "app.log"), WithGzip(true), WithSidecar(true),
+		WithRotateInterval(time.Minute), WithClock(&stepClock{now: time.Now()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for i := 0; i < 3; i++ {
+		if _, err := writer.Write([]byte("test\n")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	problems, err := writer.Verify(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 0 {
+		t.Fatalf("healthy backups incorrect, got:%+v", problems)
+	}
+
+	backups, err := writer.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// truncate the first backup, rewrite the second and add one after a gap
+	data, err := ioutil.ReadFile(backups[0].Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(backups[0].Name, data[:len(data)-4], 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeGzip(t, backups[1].Name, "other\n")
+	late := filepath.Join(dir, "app-"+backups[2].Timestamp.Add(time.Hour).UTC().Format(time.RFC3339)+".log.gz")
+	writeGzip(t, late, "late\n")
+	writer.opt.sidecar = false
+	if problems, err = writer.Verify(ctx); err != nil {
+		t.Fatal(err)
+	}
+	want := []Problem{
+		{Backup: backups[0].Name, Kind: ProblemCorrupt},
+		{Backup: backups[0].Name, Kind: ProblemChecksum},
+		{Backup: backups[1].Name, Kind: ProblemChecksum},
+		{Backup: late, Kind: ProblemGap},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("problems incorrect, got:%+v", problems)
+	}
+	for i, p := range problems {
+		if p.Backup != want[i].Backup || p.Kind != want[i].Kind {
+			t.Errorf("problem %d incorrect, got:%+v want:%v %v", i, p, want[i].Backup, want[i].Kind)
+		}
+	}
+}
+
+// writeGzip replace the gzip file name with data
+func writeGzip(t *testing.T, name, data string) {
+	plain := strings.TrimSuffix(name, ".gz")
+	if err := ioutil.WriteFile(plain, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipFile(plain); err != nil {
+		t.Fatal(err)
+	}
+}
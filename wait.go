@@ -0,0 +1,52 @@
+package rotate
+
+import (
+	"context"
+)
+
+// Wait block until queued compressions, uploads and cleanups are finished,
+// it return ErrLogFileClosed if the writer is closed with tasks still pending
+func (r *RotateWriter) Wait(ctx context.Context) error {
+	r.taskMu.Lock()
+	if r.pending == 0 {
+		r.taskMu.Unlock()
+		return nil
+	}
+	idle := r.idle
+	r.taskMu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-r.postDone:
+		select {
+		case <-idle:
+			return nil
+		default:
+			return ErrLogFileClosed
+		}
+	}
+}
+
+// addTask
+func (r *RotateWriter) addTask() {
+	r.taskMu.Lock()
+	defer r.taskMu.Unlock()
+	if r.pending == 0 {
+		r.idle = make(chan struct{})
+	}
+	r.pending++
+}
+
+// doneTask
+func (r *RotateWriter) doneTask() {
+	r.taskMu.Lock()
+	defer r.taskMu.Unlock()
+	r.pending--
+	if r.pending == 0 {
+		close(r.idle)
+	}
+	r.taskCond.Broadcast()
+}
@@ -0,0 +1,57 @@
+package rotate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_Wait(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(tmpFileName, WithGzip(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backupName := writer.backupName
+	defer func(t *testing.T) {
+		if err := os.Remove(backupName + ".gz"); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatalf("idle writer should not wait, got:%v", err)
+	}
+	if _, err := writer.Write([]byte("test")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(backupName + ".gz"); err != nil {
+		t.Errorf("backup should be compressed after Wait: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,69 @@
+package rotate
+
+import "time"
+
+const subscriberBufSize = 16
+
+// RotateEvent is sent to subscribers once a backup is finalized, i.e. renamed and compressed,
+// and when WithFailoverDir switch the active file, see Listen for every step of a rotation
+type RotateEvent struct {
+	Backup string            // final path of the backup
+	Start  time.Time         // when the file became the active file
+	End    time.Time         // when the file was rotated
+	Err    error             // set if the backup could not be processed, e.g. ErrQueueFull
+	Active string            // set instead of Backup when the writer switched directory, the new active file
+	Labels map[string]string // see WithLabels, shared by the events of the writer
+}
+
+// Subscribe return a channel receiving an event for every finalized backup, events are dropped
+// while the channel buffer is full. The channel is closed by cancel or when the writer is closed.
+func (r *RotateWriter) Subscribe() (<-chan RotateEvent, func()) {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	ch := make(chan RotateEvent, subscriberBufSize)
+	if r.done.Load() {
+		close(ch)
+		return ch, func() {}
+	}
+	if r.subs == nil {
+		r.subs = make(map[int]chan RotateEvent)
+	}
+	id := r.nextSubID
+	r.nextSubID++
+	r.subs[id] = ch
+	return ch, func() {
+		r.subMu.Lock()
+		defer r.subMu.Unlock()
+		if _, ok := r.subs[id]; ok {
+			delete(r.subs, id)
+			close(ch)
+		}
+	}
+}
+
+// publish
+func (r *RotateWriter) publish(e RotateEvent) {
+	e.Labels = r.opt.labels
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	for _, ch := range r.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// closeSubscribers
+func (r *RotateWriter) closeSubscribers() {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	for id, ch := range r.subs {
+		delete(r.subs, id)
+		close(ch)
+	}
+	for id, ch := range r.listeners {
+		delete(r.listeners, id)
+		close(ch)
+	}
+}
@@ -0,0 +1,52 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRotateWriter_Subscribe(t *testing.T) {
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "temp.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFileName := tmpFile.Name()
+	defer func(t *testing.T) {
+		if err := os.Remove(tmpFileName); err != nil {
+			t.Fatal(err)
+		}
+	}(t)
+	if err := tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := NewRotateWriter(tmpFileName, WithGzip(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backupName := writer.backupName
+	defer os.Remove(backupName + ".gz")
+
+	events, cancel := writer.Subscribe()
+	defer cancel()
+	if _, err := writer.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-events:
+		if e.Backup != backupName+".gz" || e.End.Before(e.Start) {
+			t.Errorf("event incorrect, got:%+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no rotate event")
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-events; ok {
+		t.Error("channel should be closed with the writer")
+	}
+}